@@ -1,12 +1,31 @@
 package status
 
+import "time"
+
 type Report struct {
 	PluginName   string `json:"plugin_name"`
 	ErrorMessage string `json:"error_message"`
 	StatusCode   int    `json:"status_code"`
+	// CheckedAt is the time the check returned, AgeMs how long ago that was when
+	// the report was written. Both are empty for a plugin that was not checked.
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+	AgeMs     int64     `json:"age_ms,omitzero"`
+	// Severity is SeverityPass, SeverityWarn or SeverityFail.
+	Severity string `json:"severity,omitempty"`
+	// The current streak of a plugin with failure and success thresholds.
+	ConsecutiveFailures  int `json:"consecutive_failures,omitempty"`
+	ConsecutiveSuccesses int `json:"consecutive_successes,omitempty"`
+
+	// fail is set on a report that fails the probe if the plugin is critical
+	fail bool
+	// stale is set on a report of a cached result that is missing or older than
+	// the max result age, which the thresholds count on every request
+	stale bool
 }
 
 type JobsReport struct {
+	// Source is the name of the JobsChecker plugin that reported the pipeline.
+	Source       string `json:"source"`
 	Pipeline     string `json:"pipeline"`
 	Priority     uint64 `json:"priority"`
 	Ready        bool   `json:"ready"`
@@ -16,4 +35,89 @@ type JobsReport struct {
 	Reserved     int64  `json:"reserved"`
 	Driver       string `json:"driver"`
 	ErrorMessage string `json:"error_message"`
+	// Violations of the readiness rule of the pipeline, if any.
+	Violations []string `json:"violations,omitempty"`
+	// Trend of the backlog, once the pipeline has been sampled twice.
+	Trend *JobsTrend `json:"trend,omitempty"`
+	// Stuck lists why the consumers of the pipeline look hung, if they do, and
+	// StuckSince when that started.
+	Stuck      []string  `json:"stuck,omitempty"`
+	StuckSince time.Time `json:"stuck_since,omitzero"`
+	// CheckedAt is the time the pipeline states were taken, AgeMs how long ago
+	// that was when the report was written.
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+	AgeMs     int64     `json:"age_ms,omitzero"`
+}
+
+// MaintenanceRequest is the argument of the SetMaintenance rpc method and the
+// body of POST /maintenance.
+type MaintenanceRequest struct {
+	On     bool   `json:"on"`
+	Reason string `json:"reason"`
+}
+
+// ShutdownRequest is the argument of the ShutdownState rpc method.
+type ShutdownRequest struct{}
+
+// JobsRequest is the argument of the Jobs rpc method, with the filters,
+// sorting and pagination of /jobs.
+type JobsRequest struct {
+	Pipelines []string `json:"pipelines"`
+	// names of the JobsChecker plugins that reported the pipelines
+	Sources []string `json:"sources"`
+	Drivers []string `json:"drivers"`
+	// only the pipelines with this ready state, all if nil
+	Ready *bool `json:"ready"`
+	// "priority", "backlog" or "name", the order of the jobs plugin if empty
+	Sort string `json:"sort"`
+	// offset of the first pipeline and max number of pipelines, 0 means all
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+// JobsResponse is the reply of the Jobs rpc method.
+type JobsResponse struct {
+	Reports []*JobsReport `json:"reports"`
+	// number of selected pipelines before the pagination
+	Total int `json:"total"`
+}
+
+// ProbeRequest is the argument of the StatusAll and ReadyAll rpc methods.
+type ProbeRequest struct {
+	// Plugins to check, all of them if empty.
+	Plugins []string `json:"plugins"`
+}
+
+// ProbeResponse is the reply of the StatusAll and ReadyAll rpc methods, the
+// aggregated report /health or /ready would respond with.
+type ProbeResponse struct {
+	// Status is the overall SeverityPass, SeverityWarn or SeverityFail.
+	Status string `json:"status"`
+	// Code is the status code of the matching http response.
+	Code int `json:"code"`
+	// Message is why the plugins were not checked, during the shutdown or the
+	// maintenance.
+	Message string    `json:"message,omitempty"`
+	Reports []*Report `json:"reports"`
+}
+
+// PluginsRequest is the argument of the Plugins rpc method.
+type PluginsRequest struct{}
+
+// PluginsResponse is the reply of the Plugins rpc method.
+type PluginsResponse struct {
+	Plugins []PluginInfo `json:"plugins"`
+}
+
+// HistoryRequest is the argument of the History rpc method.
+type HistoryRequest struct {
+	// Probe is "health" or "ready".
+	Probe string `json:"probe"`
+	// Plugins to return the history of, all of them if empty.
+	Plugins []string `json:"plugins"`
+}
+
+// HistoryResponse is the reply of the History rpc method.
+type HistoryResponse struct {
+	Entries []HistoryEntry `json:"entries"`
 }
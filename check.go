@@ -0,0 +1,196 @@
+package status
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/roadrunner-server/api-plugins/v6/status"
+)
+
+const timeoutQuery string = "timeout"
+
+var (
+	// errCheckTimeout is returned (wrapped) by runCheck when the check did not return within its deadline.
+	errCheckTimeout = stderr.New("check timed out")
+	// errCheckPanic is returned (wrapped) by runCheck when the check panicked.
+	errCheckPanic = stderr.New("check panicked")
+)
+
+// checkResult carries the return values of a Checker.Status or Readiness.Ready
+// call out of the goroutine it runs in.
+type checkResult struct {
+	st  *status.Status
+	err error
+	// when the call returned and how long it took; for a result shared by a
+	// coalescer, the ones of the call that produced it
+	checkedAt time.Time
+	duration  time.Duration
+}
+
+// timed wraps a Status or Ready call, stamping its result with the time it
+// returned and how long it took.
+func timed(fn func() (*status.Status, error)) func() checkResult {
+	return func() checkResult {
+		start := time.Now()
+		st, err := fn()
+
+		return checkResult{st: st, err: err, checkedAt: time.Now(), duration: time.Since(start)}
+	}
+}
+
+// runCheck calls fn in its own goroutine and waits at most timeout (no limit if
+// timeout is 0) or until ctx is done. A panic inside fn is recovered and returned
+// as errCheckPanic. Status and Ready take no context, so a check that missed its
+// deadline keeps running in the background until it returns; its result is
+// dropped. A result fn did not stamp gets the time runCheck returned.
+func runCheck(ctx context.Context, timeout time.Duration, fn func() checkResult) checkResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// buffered, so the goroutine of a timed out check does not block forever on send
+	resCh := make(chan checkResult, 1)
+	start := time.Now()
+
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				resCh <- checkResult{err: fmt.Errorf("%w: %v", errCheckPanic, rec)}
+			}
+		}()
+
+		resCh <- fn()
+	}()
+
+	var res checkResult
+	select {
+	case res = <-resCh:
+	case <-ctx.Done():
+		if stderr.Is(ctx.Err(), context.DeadlineExceeded) {
+			res.err = fmt.Errorf("%w after %s", errCheckTimeout, timeout)
+		} else {
+			res.err = fmt.Errorf("%w: %w", errCheckTimeout, ctx.Err())
+		}
+	}
+
+	if res.checkedAt.IsZero() {
+		res.checkedAt, res.duration = time.Now(), time.Since(start)
+	}
+
+	return res
+}
+
+// checkTarget is a single Status or Ready call fanned out by runChecks.
+type checkTarget struct {
+	name  string
+	check func() checkResult
+}
+
+// runChecks calls runCheck for every target, at most limit calls at a time (no
+// limit if limit <= 0), and waits for all of them. The results keep the order of
+// targets.
+func runChecks(ctx context.Context, timeout time.Duration, limit int, targets []checkTarget) []checkResult {
+	results := make([]checkResult, len(targets))
+
+	if limit <= 0 || limit > len(targets) {
+		limit = len(targets)
+	}
+
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		sem <- struct{}{}
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+
+			results[i] = runCheck(ctx, timeout, t.check)
+		})
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// abortedReport returns the report for a check that timed out or panicked, or
+// whose cached result is missing or stale, or nil if err is an error the check
+// itself returned.
+func abortedReport(name string, err error) *Report {
+	switch {
+	case stderr.Is(err, errCheckTimeout):
+		return &Report{
+			PluginName:   name,
+			ErrorMessage: err.Error(),
+			StatusCode:   http.StatusGatewayTimeout,
+		}
+	case stderr.Is(err, errCheckPanic):
+		return &Report{
+			PluginName:   name,
+			ErrorMessage: err.Error(),
+			StatusCode:   http.StatusInternalServerError,
+		}
+	case stderr.Is(err, errNotChecked), stderr.Is(err, errStaleResult):
+		return &Report{
+			PluginName:   name,
+			ErrorMessage: err.Error(),
+			StatusCode:   http.StatusServiceUnavailable,
+			stale:        true,
+		}
+	default:
+		return nil
+	}
+}
+
+// stampReports copies the check time of results onto reports, which were built
+// from results in the same order.
+func stampReports(reports []*Report, results []checkResult) {
+	now := time.Now()
+	for i, res := range results {
+		if res.checkedAt.IsZero() {
+			continue
+		}
+
+		reports[i].CheckedAt = res.checkedAt
+		reports[i].AgeMs = now.Sub(res.checkedAt).Milliseconds()
+	}
+}
+
+// requestTimeout returns the per-check deadline of the request: the ?timeout=
+// query parameter if present, capped at def, otherwise def. The parameter is a
+// Go duration ("500ms", "2s") or a number of seconds.
+func requestTimeout(r *http.Request, def time.Duration) (time.Duration, error) {
+	raw := r.URL.Query().Get(timeoutQuery)
+	if raw == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		sec, errA := strconv.Atoi(raw)
+		if errA != nil {
+			return 0, fmt.Errorf("invalid timeout %q: %w", raw, err)
+		}
+
+		d = time.Duration(sec) * time.Second
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must be positive", raw)
+	}
+
+	// the query may only shorten the configured deadline
+	if def > 0 && d > def {
+		return def, nil
+	}
+
+	return d, nil
+}
@@ -0,0 +1,182 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCheck(t *testing.T) {
+	t.Run("Result", func(t *testing.T) {
+		res := runCheck(context.Background(), time.Second, timed(func() (*apiStatus.Status, error) {
+			return &apiStatus.Status{Code: 200}, nil
+		}))
+		require.NoError(t, res.err)
+		assert.Equal(t, 200, res.st.Code)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		res := runCheck(context.Background(), 0, timed(func() (*apiStatus.Status, error) {
+			return nil, errors.New("connection refused")
+		}))
+		require.EqualError(t, res.err, "connection refused")
+		assert.Nil(t, abortedReport("http", res.err))
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+
+		res := runCheck(context.Background(), time.Millisecond*10, timed(func() (*apiStatus.Status, error) {
+			<-release
+			return &apiStatus.Status{Code: 200}, nil
+		}))
+		require.ErrorIs(t, res.err, errCheckTimeout)
+		assert.False(t, res.checkedAt.IsZero())
+
+		rep := abortedReport("http", res.err)
+		require.NotNil(t, rep)
+		assert.Equal(t, http.StatusGatewayTimeout, rep.StatusCode)
+		assert.Equal(t, "check timed out after 10ms", rep.ErrorMessage)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		res := runCheck(ctx, 0, timed(func() (*apiStatus.Status, error) {
+			<-release
+			return nil, nil
+		}))
+		require.ErrorIs(t, res.err, errCheckTimeout)
+		require.ErrorIs(t, res.err, context.Canceled)
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		res := runCheck(context.Background(), time.Second, timed(func() (*apiStatus.Status, error) {
+			panic("nil map")
+		}))
+		require.ErrorIs(t, res.err, errCheckPanic)
+
+		rep := abortedReport("http", res.err)
+		require.NotNil(t, rep)
+		assert.Equal(t, http.StatusInternalServerError, rep.StatusCode)
+		assert.Equal(t, "check panicked: nil map", rep.ErrorMessage)
+	})
+}
+
+func TestRunChecks(t *testing.T) {
+	t.Run("Order", func(t *testing.T) {
+		targets := make([]checkTarget, 0, 20)
+		for i := range 20 {
+			targets = append(targets, checkTarget{
+				name: strconv.Itoa(i),
+				check: timed(func() (*apiStatus.Status, error) {
+					return &apiStatus.Status{Code: 200 + i}, nil
+				}),
+			})
+		}
+
+		results := runChecks(context.Background(), time.Second, 3, targets)
+		require.Len(t, results, 20)
+		for i, res := range results {
+			require.NoError(t, res.err)
+			assert.Equal(t, 200+i, res.st.Code)
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		var running, peak atomic.Int32
+
+		check := func() (*apiStatus.Status, error) {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond * 10)
+			running.Add(-1)
+
+			return &apiStatus.Status{Code: 200}, nil
+		}
+
+		targets := make([]checkTarget, 0, 12)
+		for i := range 12 {
+			targets = append(targets, checkTarget{name: strconv.Itoa(i), check: timed(check)})
+		}
+
+		runChecks(context.Background(), time.Second, 4, targets)
+		assert.LessOrEqual(t, peak.Load(), int32(4))
+		assert.Greater(t, peak.Load(), int32(1))
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		// every check waits for all the others to start, which only returns in
+		// time if they run at the same time
+		var started sync.WaitGroup
+		started.Add(5)
+
+		check := func() (*apiStatus.Status, error) {
+			started.Done()
+			started.Wait()
+			return &apiStatus.Status{Code: 200}, nil
+		}
+
+		targets := make([]checkTarget, 0, 5)
+		for i := range 5 {
+			targets = append(targets, checkTarget{name: strconv.Itoa(i), check: timed(check)})
+		}
+
+		for _, res := range runChecks(context.Background(), time.Second*5, 0, targets) {
+			require.NoError(t, res.err)
+		}
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		query   string
+		def     time.Duration
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "no query", query: "", def: time.Minute, want: time.Minute},
+		{name: "duration", query: "?timeout=500ms", def: time.Minute, want: time.Millisecond * 500},
+		{name: "seconds", query: "?timeout=2", def: time.Minute, want: time.Second * 2},
+		{name: "capped at default", query: "?timeout=5m", def: time.Minute, want: time.Minute},
+		{name: "no default", query: "?timeout=5m", def: 0, want: time.Minute * 5},
+		{name: "zero", query: "?timeout=0", def: time.Minute, wantErr: true},
+		{name: "negative", query: "?timeout=-1s", def: time.Minute, wantErr: true},
+		{name: "garbage", query: "?timeout=soon", def: time.Minute, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health"+tt.query, nil)
+
+			got, err := requestTimeout(req, tt.def)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package status
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/roadrunner-server/api-plugins/v6/status"
+)
+
+// flight is a single Status or Ready call shared by every caller that asked for
+// the same plugin while it was running, or within the result TTL after it
+// returned.
+type flight struct {
+	done chan struct{}
+	st   *status.Status
+	err  error
+	// written before done is closed
+	checkedAt time.Time
+	duration  time.Duration
+	expires   time.Time
+}
+
+// coalescer deduplicates concurrent checks of the same plugin, singleflight
+// style. The http handlers and the rpc service of one probe type share a
+// coalescer, so a probe storm results in a single call per plugin.
+type coalescer struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+	// how long a returned result is handed out to new callers, 0 means only
+	// callers arriving while the call runs share it
+	ttl time.Duration
+}
+
+func newCoalescer(ttl time.Duration) *coalescer {
+	return &coalescer{
+		flights: make(map[string]*flight),
+		ttl:     ttl,
+	}
+}
+
+// do calls fn, unless a call for name is already running or its result is still
+// within the TTL, in which case it waits for and returns that result, stamped
+// with the time that call returned. A panic in fn is recovered and returned as
+// errCheckPanic to every caller.
+func (c *coalescer) do(name string, fn func() (*status.Status, error)) (res checkResult) {
+	c.mu.Lock()
+	if f, ok := c.flights[name]; ok && !f.expired() {
+		c.mu.Unlock()
+
+		<-f.done
+		return f.result()
+	}
+
+	f := &flight{done: make(chan struct{})}
+	c.flights[name] = f
+	c.mu.Unlock()
+
+	start := time.Now()
+	defer func() {
+		if rec := recover(); rec != nil {
+			f.st, f.err = nil, fmt.Errorf("%w: %v", errCheckPanic, rec)
+		}
+
+		f.checkedAt, f.duration = time.Now(), time.Since(start)
+		f.expires = f.checkedAt.Add(c.ttl)
+		close(f.done)
+
+		res = f.result()
+	}()
+
+	f.st, f.err = fn()
+
+	return res
+}
+
+// wrap returns fn with its calls coalesced under name. A nil coalescer returns
+// fn as is, timed.
+func (c *coalescer) wrap(name string, fn func() (*status.Status, error)) func() checkResult {
+	if c == nil {
+		return timed(fn)
+	}
+
+	return func() checkResult {
+		return c.do(name, fn)
+	}
+}
+
+// result returns the outcome of a returned flight.
+func (f *flight) result() checkResult {
+	return checkResult{st: f.st, err: f.err, checkedAt: f.checkedAt, duration: f.duration}
+}
+
+// expired reports whether the flight returned and its result is past the TTL.
+// A running flight never expires.
+func (f *flight) expired() bool {
+	select {
+	case <-f.done:
+		return !time.Now().Before(f.expires)
+	default:
+		return false
+	}
+}
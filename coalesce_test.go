@@ -0,0 +1,166 @@
+package status
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalescer(t *testing.T) {
+	t.Run("SharedCall", func(t *testing.T) {
+		c := newCoalescer(0)
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		fn := func() (*apiStatus.Status, error) {
+			calls.Add(1)
+			<-release
+			return &apiStatus.Status{Code: 200}, nil
+		}
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				res := c.do("http", fn)
+				assert.NoError(t, res.err)
+				assert.Equal(t, 200, res.st.Code)
+			})
+		}
+
+		// let the callers pile up on the running call
+		require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(time.Millisecond * 20)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+
+		// without a TTL, a call after the shared one returned checks again
+		require.NoError(t, c.do("http", fn).err)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("PerPlugin", func(t *testing.T) {
+		c := newCoalescer(time.Minute)
+
+		for i := range 3 {
+			res := c.do("plugin"+strconv.Itoa(i), func() (*apiStatus.Status, error) {
+				return &apiStatus.Status{Code: 200 + i}, nil
+			})
+			require.NoError(t, res.err)
+			assert.Equal(t, 200+i, res.st.Code)
+		}
+	})
+
+	t.Run("TTL", func(t *testing.T) {
+		c := newCoalescer(time.Millisecond * 50)
+
+		var calls atomic.Int32
+		fn := func() (*apiStatus.Status, error) {
+			calls.Add(1)
+			return &apiStatus.Status{Code: 200}, nil
+		}
+
+		first := c.do("http", fn)
+		require.NoError(t, first.err)
+
+		// the shared result carries the time of the call that produced it
+		for range 4 {
+			res := c.do("http", fn)
+			require.NoError(t, res.err)
+			assert.Equal(t, first.checkedAt, res.checkedAt)
+			assert.Equal(t, first.duration, res.duration)
+		}
+		assert.Equal(t, int32(1), calls.Load())
+
+		time.Sleep(time.Millisecond * 60)
+
+		res := c.do("http", fn)
+		require.NoError(t, res.err)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.True(t, res.checkedAt.After(first.checkedAt))
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		c := newCoalescer(time.Minute)
+
+		res := c.do("http", func() (*apiStatus.Status, error) {
+			panic("nil map")
+		})
+		require.ErrorIs(t, res.err, errCheckPanic)
+
+		// the panic is shared like any other result
+		res = c.do("http", func() (*apiStatus.Status, error) {
+			return &apiStatus.Status{Code: 200}, nil
+		})
+		require.ErrorIs(t, res.err, errCheckPanic)
+	})
+
+	t.Run("NilCoalescer", func(t *testing.T) {
+		var c *coalescer
+
+		res := c.wrap("http", func() (*apiStatus.Status, error) {
+			return &apiStatus.Status{Code: 200}, nil
+		})()
+		require.NoError(t, res.err)
+		assert.Equal(t, 200, res.st.Code)
+		assert.False(t, res.checkedAt.IsZero())
+	})
+}
+
+// TestCoalescedHandlers checks that a /ready request and an rpc call arriving
+// together share a single Ready call.
+func TestCoalescedHandlers(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true}, initLogger{}))
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	p.readyRegistry["http"] = &countingReadiness{calls: &calls, release: release}
+
+	h := NewReadyHandler(p.readyRegistry, newShutdownPtr(false), p.log, http.StatusServiceUnavailable, withCoalescer(p.readyFlights))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+	wg.Go(func() {
+		st, err := p.ready("http")
+		assert.NoError(t, err)
+		assert.Equal(t, 200, st.Code)
+	})
+
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(time.Millisecond * 20)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+// countingReadiness counts its Ready calls, each of which waits for release.
+type countingReadiness struct {
+	calls   *atomic.Int32
+	release chan struct{}
+}
+
+func (c *countingReadiness) Ready() (*apiStatus.Status, error) {
+	c.calls.Add(1)
+	<-c.release
+	return &apiStatus.Status{Code: 200}, nil
+}
+
+func (c *countingReadiness) Name() string { return "http" }
@@ -1,17 +1,114 @@
 package status
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 // Config is the configuration reference for the Status plugin
 type Config struct {
 	// Address of the http server
 	Address string
-	// Time to wait for a health check response.
+	// Time to wait for a health check response, in seconds. Bounds every single
+	// Status/Ready call as well as reading the probe request.
 	CheckTimeout int `mapstructure:"check_timeout"`
+	// Max number of plugins checked concurrently by a single request, 10 by default.
+	CheckConcurrency int `mapstructure:"check_concurrency"`
+	// How long the result of a check is shared with requests arriving after it
+	// returned. Concurrent requests always share a running check. 0 by default.
+	ResultTTL time.Duration `mapstructure:"result_ttl"`
+	// Number of check results kept per plugin and probe type for the history
+	// endpoints, 100 by default.
+	HistorySize int `mapstructure:"history_size"`
+	// Bearer token of POST /maintenance, which is only served when it is set.
+	MaintenanceToken string `mapstructure:"maintenance_token"`
+	// Whether the maintenance mode fails /jobs as well as /ready.
+	MaintenanceJobs bool `mapstructure:"maintenance_jobs"`
+	// Readiness rules of the job pipelines reported by /jobs.
+	Jobs *JobsConfig `mapstructure:"jobs"`
+	// Whether an unknown or nil plugin named in ?plugin= fails /health and
+	// /ready instead of being skipped.
+	StrictPlugins bool `mapstructure:"strict_plugins"`
+	// serviceId of the application/health+json responses of /health and /ready.
+	ServiceID string `mapstructure:"service_id"`
+	// OTLP export of the spans of the probe requests and their checks. Disabled
+	// by default.
+	Tracing *TracingConfig `mapstructure:"tracing"`
+	// grpc.health.v1.Health server, on an address of its own. Disabled by default.
+	GRPC *GRPCConfig `mapstructure:"grpc"`
+	// Per-plugin settings, keyed by plugin name.
+	Plugins map[string]*PluginConfig `mapstructure:"plugins"`
+	// Readiness plugins the /startup probe waits for, all of them by default.
+	StartupPlugins []string `mapstructure:"startup_plugins"`
+	// Time after the start during which /startup failures are expected.
+	StartupGracePeriod time.Duration `mapstructure:"startup_grace_period"`
+	// Time Stop keeps serving the unavailable status code on /ready before it
+	// shuts the http server down, so load balancers notice the drain. 0 by default.
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay"`
+	// Interval of the background polling. When set, every plugin is checked on
+	// this interval and the endpoints serve the latest results instead of
+	// checking on each request. Disabled by default.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// Age after which a polled result counts as failing, three poll intervals by default.
+	MaxResultAge time.Duration `mapstructure:"max_result_age"`
 	// Status code returned in case of fail, 503 by default
 	UnavailableStatusCode int `mapstructure:"unavailable_status_code"`
 }
 
+// PluginConfig is the configuration of the checks of a single plugin.
+type PluginConfig struct {
+	// A failing critical plugin fails /health and /ready with the unavailable
+	// status code, a failing non-critical one only degrades the overall status
+	// to warn. Plugins are critical by default.
+	Critical *bool `mapstructure:"critical"`
+	// Consecutive failures before a passing plugin fails the probe, 1 by default.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// Consecutive successes before a failing plugin passes the probe again, 1 by default.
+	SuccessThreshold int `mapstructure:"success_threshold"`
+}
+
+// JobsConfig is the configuration of the readiness rules and the sampling of
+// the job pipelines.
+type JobsConfig struct {
+	// Rules keyed by pipeline name.
+	Pipelines map[string]JobsRule `mapstructure:"pipelines"`
+	// Rules keyed by driver name, for the pipelines without a rule of their own.
+	Drivers map[string]JobsRule `mapstructure:"drivers"`
+	// Whether a pipeline breaking its rule fails /ready as well.
+	Ready bool `mapstructure:"ready"`
+	// Interval of the sampling of the pipelines the trends are derived from.
+	// Disabled by default.
+	SampleInterval time.Duration `mapstructure:"sample_interval"`
+	// Number of samples kept per pipeline, 60 by default.
+	SampleSize int `mapstructure:"sample_size"`
+	// A pipeline is stuck when its non-zero active and reserved jobs stay the
+	// same for StuckAfter, or when it is not ready for longer than
+	// NotReadyGrace. Both are disabled by default.
+	StuckAfter    time.Duration `mapstructure:"stuck_after"`
+	NotReadyGrace time.Duration `mapstructure:"not_ready_grace"`
+	// Whether a stuck pipeline fails /ready as well. Requires StuckAfter or
+	// NotReadyGrace.
+	StuckReady bool `mapstructure:"stuck_ready"`
+}
+
+// TracingConfig is the configuration of the OTLP/HTTP span exporter.
+type TracingConfig struct {
+	// host:port of the collector.
+	Endpoint string `mapstructure:"endpoint"`
+	// Export over http instead of https.
+	Insecure bool `mapstructure:"insecure"`
+	// service.name of the exported spans, "rr_status" by default.
+	ServiceName string `mapstructure:"service_name"`
+}
+
+// GRPCConfig is the configuration of the gRPC Health Checking Protocol server.
+type GRPCConfig struct {
+	// Address of the grpc server, which is only started when it is set.
+	Address string `mapstructure:"address"`
+	// Interval at which Watch checks the watched service, 5s by default.
+	WatchInterval time.Duration `mapstructure:"watch_interval"`
+}
+
 // InitDefaults configuration options
 func (c *Config) InitDefaults() {
 	if c.UnavailableStatusCode == 0 {
@@ -20,4 +117,95 @@ func (c *Config) InitDefaults() {
 	if c.CheckTimeout <= 0 {
 		c.CheckTimeout = 60
 	}
+	if c.CheckConcurrency <= 0 {
+		c.CheckConcurrency = 10
+	}
+	if c.HistorySize <= 0 {
+		c.HistorySize = 100
+	}
+	for name, pc := range c.Plugins {
+		if pc == nil {
+			pc = &PluginConfig{}
+			c.Plugins[name] = pc
+		}
+		if pc.Critical == nil {
+			pc.Critical = new(true)
+		}
+		if pc.FailureThreshold <= 0 {
+			pc.FailureThreshold = 1
+		}
+		if pc.SuccessThreshold <= 0 {
+			pc.SuccessThreshold = 1
+		}
+	}
+	if c.Jobs != nil && c.Jobs.SampleSize <= 0 {
+		c.Jobs.SampleSize = 60
+	}
+	if c.Tracing != nil && c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "rr_status"
+	}
+	if c.GRPC != nil && c.GRPC.WatchInterval <= 0 {
+		c.GRPC.WatchInterval = 5 * time.Second
+	}
+	if c.PollInterval > 0 && c.MaxResultAge <= 0 {
+		c.MaxResultAge = 3 * c.PollInterval
+	}
+}
+
+// Validate rejects the options that cannot take effect.
+func (c *Config) Validate() error {
+	if c.Jobs != nil && c.Jobs.StuckReady && c.stuckDetector() == nil {
+		return errStuckReadyDisabled
+	}
+
+	return nil
+}
+
+// checkTimeout returns CheckTimeout as a duration.
+func (c *Config) checkTimeout() time.Duration {
+	return time.Duration(c.CheckTimeout) * time.Second
+}
+
+// nonCriticalPlugins returns the plugins configured as non-critical.
+func (c *Config) nonCriticalPlugins() []string {
+	var names []string
+	for name, pc := range c.Plugins {
+		if pc.Critical != nil && !*pc.Critical {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// jobsRules returns the readiness rules of the job pipelines, nil if none are
+// configured.
+func (c *Config) jobsRules() *jobsRules {
+	if c.Jobs == nil {
+		return nil
+	}
+
+	return &jobsRules{pipelines: c.Jobs.Pipelines, drivers: c.Jobs.Drivers}
+}
+
+// stuckDetector returns the detector of the stuck job pipelines, nil if the
+// detection is disabled.
+func (c *Config) stuckDetector() *stuckDetector {
+	if c.Jobs == nil || (c.Jobs.StuckAfter <= 0 && c.Jobs.NotReadyGrace <= 0) {
+		return nil
+	}
+
+	return newStuckDetector(c.Jobs.StuckAfter, c.Jobs.NotReadyGrace)
+}
+
+// thresholds returns the thresholds of the plugins that have any above 1.
+func (c *Config) thresholds() map[string]Thresholds {
+	th := make(map[string]Thresholds)
+	for name, pc := range c.Plugins {
+		if pc.FailureThreshold > 1 || pc.SuccessThreshold > 1 {
+			th[name] = Thresholds{Failure: pc.FailureThreshold, Success: pc.SuccessThreshold}
+		}
+	}
+
+	return th
 }
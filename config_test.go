@@ -3,8 +3,10 @@ package status
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConfigInitDefaults(t *testing.T) {
@@ -13,24 +15,28 @@ func TestConfigInitDefaults(t *testing.T) {
 		cfg          Config
 		wantCode     int
 		wantTimeoutS int
+		wantWorkers  int
 	}{
 		{
 			name:         "zero value",
 			cfg:          Config{},
 			wantCode:     http.StatusServiceUnavailable,
 			wantTimeoutS: 60,
+			wantWorkers:  10,
 		},
 		{
 			name:         "negative check timeout",
 			cfg:          Config{CheckTimeout: -1},
 			wantCode:     http.StatusServiceUnavailable,
 			wantTimeoutS: 60,
+			wantWorkers:  10,
 		},
 		{
 			name:         "configured values are kept",
-			cfg:          Config{CheckTimeout: 5, UnavailableStatusCode: http.StatusInternalServerError},
+			cfg:          Config{CheckTimeout: 5, UnavailableStatusCode: http.StatusInternalServerError, CheckConcurrency: 2},
 			wantCode:     http.StatusInternalServerError,
 			wantTimeoutS: 5,
+			wantWorkers:  2,
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
@@ -39,6 +45,81 @@ func TestConfigInitDefaults(t *testing.T) {
 
 			assert.Equal(t, tt.wantCode, cfg.UnavailableStatusCode)
 			assert.Equal(t, tt.wantTimeoutS, cfg.CheckTimeout)
+			assert.Equal(t, tt.wantWorkers, cfg.CheckConcurrency)
 		})
 	}
 }
+
+func TestConfigHistorySize(t *testing.T) {
+	cfg := Config{}
+	cfg.InitDefaults()
+	assert.Equal(t, 100, cfg.HistorySize)
+
+	cfg = Config{HistorySize: 10}
+	cfg.InitDefaults()
+	assert.Equal(t, 10, cfg.HistorySize)
+}
+
+func TestConfigJobsSampleSize(t *testing.T) {
+	cfg := Config{Jobs: &JobsConfig{SampleInterval: time.Second}}
+	cfg.InitDefaults()
+	assert.Equal(t, 60, cfg.Jobs.SampleSize)
+
+	cfg = Config{Jobs: &JobsConfig{SampleInterval: time.Second, SampleSize: 5}}
+	cfg.InitDefaults()
+	assert.Equal(t, 5, cfg.Jobs.SampleSize)
+}
+
+func TestConfigStuckDetector(t *testing.T) {
+	assert.Nil(t, (&Config{}).stuckDetector())
+	assert.Nil(t, (&Config{Jobs: &JobsConfig{}}).stuckDetector())
+
+	d := (&Config{Jobs: &JobsConfig{StuckAfter: time.Minute}}).stuckDetector()
+	require.NotNil(t, d)
+	assert.Equal(t, time.Minute, d.stuckAfter)
+	assert.Zero(t, d.notReadyGrace)
+}
+
+func TestConfigValidate(t *testing.T) {
+	require.NoError(t, (&Config{}).Validate())
+	require.NoError(t, (&Config{Jobs: &JobsConfig{StuckReady: true, NotReadyGrace: time.Minute}}).Validate())
+
+	// a stuck_ready check without the stuck detection would always pass
+	err := (&Config{Jobs: &JobsConfig{StuckReady: true}}).Validate()
+	require.ErrorIs(t, err, errStuckReadyDisabled)
+
+	p := &Plugin{}
+	err = p.Init(&initConfigurer{has: true, cfg: &Config{Jobs: &JobsConfig{StuckReady: true}}}, initLogger{})
+	// errors.E does not unwrap
+	require.ErrorContains(t, err, errStuckReadyDisabled.Error())
+}
+
+func TestConfigMaxResultAge(t *testing.T) {
+	cfg := Config{PollInterval: time.Second * 5}
+	cfg.InitDefaults()
+	assert.Equal(t, time.Second*15, cfg.MaxResultAge)
+
+	cfg = Config{PollInterval: time.Second * 5, MaxResultAge: time.Minute}
+	cfg.InitDefaults()
+	assert.Equal(t, time.Minute, cfg.MaxResultAge)
+
+	// polling disabled
+	cfg = Config{}
+	cfg.InitDefaults()
+	assert.Zero(t, cfg.MaxResultAge)
+}
+
+func TestConfigPlugins(t *testing.T) {
+	cfg := Config{Plugins: map[string]*PluginConfig{
+		"http": nil,
+		"kv":   {Critical: new(false)},
+		"grpc": {Critical: new(true), FailureThreshold: 3},
+	}}
+	cfg.InitDefaults()
+
+	assert.True(t, *cfg.Plugins["http"].Critical)
+	assert.Equal(t, 1, cfg.Plugins["http"].FailureThreshold)
+	assert.Equal(t, 1, cfg.Plugins["http"].SuccessThreshold)
+	assert.Equal(t, []string{"kv"}, cfg.nonCriticalPlugins())
+	assert.Equal(t, map[string]Thresholds{"grpc": {Failure: 3, Success: 1}}, cfg.thresholds())
+}
@@ -2,20 +2,97 @@
 // methods for monitoring the health, readiness, and job queue state of
 // registered plugins.
 //
-// The plugin starts an HTTP server with three endpoints:
+// The plugin starts an HTTP server with the following endpoints:
 //
 //   - /health – returns the aggregated health status of every plugin that
 //     implements the [Checker] interface.
 //   - /ready  – returns the readiness status of every plugin that implements
 //     the [Readiness] interface.
-//   - /jobs   – returns the state of job pipelines from a plugin that
-//     implements the [JobsChecker] interface.
+//   - /startup – returns 200 once every Readiness plugin (or the configured
+//     startup_plugins) has been ready once, and keeps returning it from then
+//     on. Meant for the Kubernetes startupProbe. Within startup_grace_period
+//     the plugins not ready yet are warnings and Retry-After tells when it
+//     ends.
+//   - /jobs   – returns the state of job pipelines from every plugin that
+//     implements the [JobsChecker] interface, labeled with its source plugin,
+//     failing while a pipeline breaks its configured readiness rule. The
+//     pipelines can be filtered with ?pipeline=, ?source=, ?driver= and
+//     ?ready=, sorted with ?sort=priority, backlog or name, and paginated with
+//     ?offset= and ?limit=; /jobs/{pipeline} returns a single pipeline, 409 if
+//     several plugins report it and ?source= selects none of them.
+//   - /jobs/history – returns the samples of the pipelines, with
+//     jobs.sample_interval set; /jobs then reports the backlog trend of each.
+//     With jobs.stuck_after or jobs.not_ready_grace set, /jobs also flags the
+//     pipelines whose consumers look hung.
+//   - /plugins – lists the collected plugins, the interfaces they implement
+//     and the probes they take part in, i.e. the names ?plugin= accepts.
+//   - /metrics – exports the last status code and pass state of every plugin
+//     reported by /health and /ready, the durations of the checks, the state of
+//     the job pipelines and whether the shutdown is in progress, in the
+//     Prometheus format. With poll_interval or jobs.sample_interval set, the
+//     pipelines are the latest polled or sampled ones instead of fresh ones.
+//   - /livez, /readyz – the checks of /health and /ready with the semantics of
+//     the kube-apiserver: "ok" on pass, otherwise, or with ?verbose, a
+//     "[+]plugin ok" or "[-]plugin failed: reason" line per check.
+//     ?exclude= skips the named plugins, and /livez/{plugin} and
+//     /readyz/{plugin} check a single one.
+//   - /health/history, /ready/history – return the latest check results of
+//     every plugin, oldest first, marking each change of its severity.
 //
-// During graceful shutdown /ready and /jobs respond with the configured
-// unavailable status code (503 by default) so external load balancers can drain
-// traffic, while /health stays 200 (liveness) so the orchestrator does not kill
-// the still-draining process.
+// The plugins of a request are checked concurrently, up to the configured check
+// concurrency. Every Status and Ready call runs under the configured check
+// timeout, which a request may shorten with the ?timeout= query parameter. A
+// check that times out or panics is reported on its own; the other plugins are
+// still checked. A plugin named in ?plugin= that is not registered is skipped,
+// unless strict_plugins is set: it then fails the probe with a 404 report.
 //
-// An RPC service is also registered, providing Status and Ready methods for
-// programmatic access from RoadRunner workers or CLI tools.
+// With Accept: application/health+json, /health and /ready respond with a
+// single document in the Health Check Response Format for HTTP APIs draft: the
+// overall status, the errors as output, and a check per plugin.
+//
+// With grpc.address set, the plugin also serves the grpc.health.v1.Health
+// service. A service name is a plugin name, checked as by /ready for a
+// Readiness plugin and as by /health for a Checker plugin; the empty service
+// name is the aggregate of /ready. During the shutdown every service is
+// NOT_SERVING.
+//
+// With tracing.endpoint set, every /health, /ready and /jobs request is traced
+// over OTLP/HTTP, continuing the trace of its traceparent header, with a child
+// span per plugin check or JobsState call.
+//
+// Plugins are critical by default. A failing non-critical plugin does not fail
+// /health or /ready, it only degrades the overall status, sent in the
+// X-Health-Status header, from pass to warn. Every report carries its severity.
+// With per-plugin failure_threshold and success_threshold, the reported state
+// of a plugin changes only after that many consecutive failures or successes.
+//
+// Concurrent requests for the same plugin and probe type, over HTTP or RPC,
+// share a single in-flight check, and with result_ttl set, its result for a
+// short while after it returned.
+//
+// With poll_interval set, the plugin checks every plugin in the background and
+// the endpoints serve the cached results; a result older than max_result_age
+// counts as failing.
+//
+// During graceful shutdown /ready, /jobs and a not yet latched /startup respond
+// with the configured unavailable status code (503 by default) so external load
+// balancers can drain traffic, while /health stays 200 (liveness) so the
+// orchestrator does not kill the still-draining process. Their JSON body, or
+// a health+json document to the requests accepting it, reports the drain
+// progress: when the shutdown started and which plugins are still running or
+// already stopped, as checked in the background every second during the
+// drain; the ShutdownState RPC method checks them at once. After
+// shutdown_delay the HTTP server shuts down, letting in-flight probes finish.
+//
+// The maintenance mode, toggled with the SetMaintenance RPC method or, with
+// maintenance_token set, an authenticated POST /maintenance, fails /ready (and
+// /jobs with maintenance_jobs) the same way without stopping RoadRunner, and
+// can be turned off again. The failed probes reply with the maintenance state,
+// or a health+json document to the requests accepting it.
+//
+// An RPC service is also registered, providing Status, Ready, StatusAll,
+// ReadyAll, Plugins, Jobs, History, SetMaintenance and ShutdownState methods
+// for programmatic access from RoadRunner workers or CLI tools without the
+// HTTP port. StatusAll and ReadyAll return the same aggregated report as
+// /health and /ready.
 package status
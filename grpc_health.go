@@ -0,0 +1,117 @@
+package status
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcStatus "google.golang.org/grpc/status"
+)
+
+// grpcHealth serves the grpc.health.v1.Health service. A service name is a
+// plugin name: a Readiness plugin is checked as by /ready, a Checker plugin as
+// by /health. The empty service name is the aggregate of /ready.
+type grpcHealth struct {
+	healthpb.UnimplementedHealthServer
+
+	srv *Plugin
+	// interval of the checks of a watched service
+	interval time.Duration
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func newGRPCHealth(srv *Plugin, interval time.Duration) *grpcHealth {
+	return &grpcHealth{
+		srv:      srv,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Check returns the serving status of the service, NotFound for a service that
+// names no collected plugin.
+func (gh *grpcHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, err := gh.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch sends the serving status of the service, then every change of it until
+// the client goes away or the plugin stops. A service that names no collected
+// plugin is SERVICE_UNKNOWN.
+func (gh *grpcHealth) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(gh.interval)
+	defer ticker.Stop()
+
+	var (
+		last healthpb.HealthCheckResponse_ServingStatus
+		sent bool
+	)
+
+	for {
+		st, err := gh.status(stream.Context(), req.GetService())
+		if err != nil {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+
+		if !sent || st != last {
+			err = stream.Send(&healthpb.HealthCheckResponse{Status: st})
+			if err != nil {
+				return err
+			}
+
+			last, sent = st, true
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return grpcStatus.FromContextError(stream.Context().Err()).Err()
+		case <-gh.stopCh:
+			return nil
+		}
+	}
+}
+
+// status checks the plugin named service, or every Readiness plugin if service
+// is empty. During the shutdown every service is NOT_SERVING, so the balancers
+// drain the traffic.
+func (gh *grpcHealth) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	probe, names := probeReady, []string(nil)
+	if service != "" {
+		names = []string{service}
+
+		if _, ok := gh.srv.readyRegistry[service]; !ok {
+			if _, ok := gh.srv.statusRegistry[service]; !ok {
+				return healthpb.HealthCheckResponse_SERVICE_UNKNOWN, grpcStatus.Errorf(codes.NotFound, "unknown service %q", service)
+			}
+
+			probe = probeHealth
+		}
+	}
+
+	if gh.srv.shutdownInitiated.Load() {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+
+	resp, err := gh.srv.probe(ctx, probe, names)
+	if err != nil || resp.Status == SeverityFail {
+		return healthpb.HealthCheckResponse_NOT_SERVING, nil
+	}
+
+	return healthpb.HealthCheckResponse_SERVING, nil
+}
+
+// stop ends the running watches.
+func (gh *grpcHealth) stop() {
+	gh.stopOnce.Do(func() {
+		close(gh.stopCh)
+	})
+}
@@ -0,0 +1,157 @@
+package status
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	grpcStatus "google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCHealthClient serves gh over an in-memory connection and returns a
+// client of it.
+func newGRPCHealthClient(t *testing.T, gh *grpcHealth) healthpb.HealthClient {
+	t.Helper()
+
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, gh)
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestGRPCHealthCheck(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true, cfg: &Config{Address: "127.0.0.1:0"}}, initLogger{}))
+
+	p.statusRegistry["logs"] = &mockChecker{name: "logs", st: &apiStatus.Status{Code: http.StatusOK}}
+	p.readyRegistry["http"] = &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}}
+	p.readyRegistry["grpc"] = &mockReadiness{name: "grpc", st: &apiStatus.Status{Code: http.StatusInternalServerError}}
+
+	gh := newGRPCHealth(p, time.Hour)
+	client := newGRPCHealthClient(t, gh)
+	ctx := context.Background()
+
+	// the handlers are not built before Serve
+	resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "http"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	p.Serve()
+	t.Cleanup(p.StopHTTPServer)
+
+	tests := []struct {
+		service string
+		want    healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{service: "", want: healthpb.HealthCheckResponse_NOT_SERVING},
+		{service: "http", want: healthpb.HealthCheckResponse_SERVING},
+		{service: "grpc", want: healthpb.HealthCheckResponse_NOT_SERVING},
+		{service: "logs", want: healthpb.HealthCheckResponse_SERVING},
+	}
+
+	for _, tt := range tests {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: tt.service})
+		require.NoError(t, err, tt.service)
+		assert.Equal(t, tt.want, resp.GetStatus(), tt.service)
+	}
+
+	_, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "kv"})
+	assert.Equal(t, codes.NotFound, grpcStatus.Code(err))
+
+	// the checks are recorded like the ones of /ready
+	assert.NotEmpty(t, p.readyHistory.entries([]string{"grpc"}))
+
+	// every service drains during the shutdown
+	p.shutdownInitiated.Store(true)
+	resp, err = client.Check(ctx, &healthpb.HealthCheckRequest{Service: "logs"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+}
+
+func TestGRPCHealthWatch(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true, cfg: &Config{Address: "127.0.0.1:0"}}, initLogger{}))
+	p.readyRegistry["http"] = &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}}
+
+	p.Serve()
+	t.Cleanup(p.StopHTTPServer)
+
+	gh := newGRPCHealth(p, 10*time.Millisecond)
+	client := newGRPCHealthClient(t, gh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	// only the changes are sent
+	p.maintenance.set(true, "upgrade")
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	p.maintenance.set(false, "")
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	unknown, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: "kv"})
+	require.NoError(t, err)
+	resp, err = unknown.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, resp.GetStatus())
+
+	// stopping ends the watches
+	gh.stop()
+	_, err = stream.Recv()
+	assert.Error(t, err)
+	_, err = unknown.Recv()
+	assert.Error(t, err)
+}
+
+func TestPluginServeGRPC(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true, cfg: &Config{
+		Address: "127.0.0.1:0",
+		GRPC:    &GRPCConfig{Address: "127.0.0.1:0"},
+	}}, initLogger{}))
+	assert.Equal(t, 5*time.Second, p.cfg.GRPC.WatchInterval)
+
+	errCh := p.Serve()
+	require.NotNil(t, p.grpcServer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, p.Stop(ctx))
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("unexpected serve error: %v", err)
+	default:
+	}
+}
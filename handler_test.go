@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
 	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
@@ -23,21 +24,47 @@ type mockChecker struct {
 	name string
 	st   *apiStatus.Status
 	err  error
+	// if set, Status waits for it to be closed before returning
+	block chan struct{}
+	// if set, Status panics with it
+	panicVal any
 }
 
-func (m *mockChecker) Status() (*apiStatus.Status, error) { return m.st, m.err }
-func (m *mockChecker) Name() string                       { return m.name }
+func (m *mockChecker) Status() (*apiStatus.Status, error) {
+	if m.block != nil {
+		<-m.block
+	}
+	if m.panicVal != nil {
+		panic(m.panicVal)
+	}
+	return m.st, m.err
+}
+func (m *mockChecker) Name() string { return m.name }
 
 type mockReadiness struct {
 	name string
 	st   *apiStatus.Status
 	err  error
+	// if set, Ready waits for it to be closed before returning
+	block chan struct{}
+	// if set, Ready panics with it
+	panicVal any
 }
 
-func (m *mockReadiness) Ready() (*apiStatus.Status, error) { return m.st, m.err }
-func (m *mockReadiness) Name() string                      { return m.name }
+func (m *mockReadiness) Ready() (*apiStatus.Status, error) {
+	if m.block != nil {
+		<-m.block
+	}
+	if m.panicVal != nil {
+		panic(m.panicVal)
+	}
+	return m.st, m.err
+}
+func (m *mockReadiness) Name() string { return m.name }
 
 type mockJobsChecker struct {
+	// "jobs" if empty
+	name   string
 	states []*jobsApi.State
 	err    error
 }
@@ -45,7 +72,22 @@ type mockJobsChecker struct {
 func (m *mockJobsChecker) JobsState(_ context.Context) ([]*jobsApi.State, error) {
 	return m.states, m.err
 }
-func (m *mockJobsChecker) Name() string { return "jobs" }
+
+func (m *mockJobsChecker) Name() string {
+	if m.name == "" {
+		return "jobs"
+	}
+	return m.name
+}
+
+// jobsRegistry keys the mocks by name, the way Plugin.Collects does.
+func jobsRegistry(jcs ...*mockJobsChecker) map[string]JobsChecker {
+	m := make(map[string]JobsChecker, len(jcs))
+	for _, jc := range jcs {
+		m[jc.Name()] = jc
+	}
+	return m
+}
 
 // failingWriter is a ResponseWriter whose body write always fails, which is how
 // a client that hangs up mid-response looks to a handler.
@@ -94,6 +136,24 @@ func parseReports(t *testing.T, body []byte) []*Report {
 	return reports
 }
 
+// newBlock returns a channel for the block field of the mocks, closed by t.Cleanup.
+func newBlock(t *testing.T) chan struct{} {
+	t.Helper()
+	ch := make(chan struct{})
+	t.Cleanup(func() { close(ch) })
+	return ch
+}
+
+// reportsByName indexes the reports by plugin name, the order of the
+// all-plugins path follows the registry map and is not stable.
+func reportsByName(reports []*Report) map[string]*Report {
+	m := make(map[string]*Report, len(reports))
+	for _, rep := range reports {
+		m[rep.PluginName] = rep
+	}
+	return m
+}
+
 func parseJobsReports(t *testing.T, body []byte) []*JobsReport {
 	t.Helper()
 	var reports []*JobsReport
@@ -275,6 +335,30 @@ func TestHealthHandler(t *testing.T) {
 		assert.Empty(t, reports)
 	})
 
+	t.Run("Filtered_Strict", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+			"grpc": nil,
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithStrictPlugins(true))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health?plugin=http&plugin=htp&plugin=grpc", nil)
+		h.ServeHTTP(rec, req)
+
+		// unknown and nil plugins fail the probe instead of being skipped
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, SeverityFail, rec.Header().Get(HealthStatusHeader))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 3)
+		assert.Equal(t, "htp", reports[0].PluginName)
+		assert.Equal(t, "plugin not found", reports[0].ErrorMessage)
+		assert.Equal(t, http.StatusNotFound, reports[0].StatusCode)
+		assert.Equal(t, "grpc", reports[1].PluginName)
+		assert.Equal(t, "plugin is nil or not initialized", reports[1].ErrorMessage)
+		assert.Equal(t, "http", reports[2].PluginName)
+		assert.Equal(t, SeverityPass, reports[2].Severity)
+	})
+
 	t.Run("Filtered_Error", func(t *testing.T) {
 		registry := map[string]Checker{
 			"http": &mockChecker{name: "http", err: errors.New("connection refused")},
@@ -356,6 +440,121 @@ func TestHealthHandler(t *testing.T) {
 		require.Len(t, reports, 1)
 		assert.Equal(t, http.StatusInternalServerError, reports[0].StatusCode)
 	})
+
+	// ---- Check deadline and panic isolation ----
+
+	t.Run("CheckTimeout", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", block: newBlock(t)},
+			"grpc": &mockChecker{name: "grpc", st: &apiStatus.Status{Code: 200}},
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithCheckTimeout(time.Millisecond*20))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		require.Len(t, reports, 2)
+		assert.Equal(t, http.StatusGatewayTimeout, reports["http"].StatusCode)
+		assert.Equal(t, "check timed out after 20ms", reports["http"].ErrorMessage)
+		assert.Equal(t, 200, reports["grpc"].StatusCode)
+	})
+
+	t.Run("CheckTimeoutQuery", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", block: newBlock(t)},
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithCheckTimeout(time.Minute))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health?plugin=http&timeout=20ms", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Equal(t, http.StatusGatewayTimeout, reports[0].StatusCode)
+	})
+
+	t.Run("InvalidTimeoutQuery", func(t *testing.T) {
+		h := NewHealthHandler(map[string]Checker{}, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health?timeout=soon", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("CheckPanic", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", panicVal: "nil map"},
+			"grpc": &mockChecker{name: "grpc", st: &apiStatus.Status{Code: 200}},
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		require.Len(t, reports, 2)
+		assert.Equal(t, http.StatusInternalServerError, reports["http"].StatusCode)
+		assert.Equal(t, "check panicked: nil map", reports["http"].ErrorMessage)
+		assert.Equal(t, 200, reports["grpc"].StatusCode)
+	})
+
+	// ---- Critical and non-critical plugins ----
+
+	t.Run("NonCriticalFailure", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+			"kv":   &mockChecker{name: "kv", st: &apiStatus.Status{Code: 500}},
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithNonCriticalPlugins("kv"))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, SeverityWarn, rec.Header().Get(HealthStatusHeader))
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		assert.Equal(t, SeverityPass, reports["http"].Severity)
+		assert.Equal(t, SeverityWarn, reports["kv"].Severity)
+		assert.Equal(t, http.StatusServiceUnavailable, reports["kv"].StatusCode)
+	})
+
+	t.Run("CriticalFailure", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 500}},
+			"kv":   &mockChecker{name: "kv", st: &apiStatus.Status{Code: 500}},
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithNonCriticalPlugins("kv"))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health?plugin=http&plugin=kv", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, SeverityFail, rec.Header().Get(HealthStatusHeader))
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		assert.Equal(t, SeverityFail, reports["http"].Severity)
+		assert.Equal(t, SeverityWarn, reports["kv"].Severity)
+	})
+
+	t.Run("Pass", func(t *testing.T) {
+		registry := map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+		}
+		h := NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, SeverityPass, rec.Header().Get(HealthStatusHeader))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Equal(t, SeverityPass, reports[0].Severity)
+	})
 }
 
 // --- Ready Handler Tests ---
@@ -532,6 +731,30 @@ func TestReadyHandler(t *testing.T) {
 		assert.Empty(t, reports)
 	})
 
+	t.Run("Filtered_Strict", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+			"grpc": nil,
+		}
+		h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithStrictPlugins(true))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http&plugin=htp&plugin=grpc", nil)
+		h.ServeHTTP(rec, req)
+
+		// unknown and nil plugins fail the probe instead of being skipped
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, SeverityFail, rec.Header().Get(HealthStatusHeader))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 3)
+		assert.Equal(t, "htp", reports[0].PluginName)
+		assert.Equal(t, "plugin not found", reports[0].ErrorMessage)
+		assert.Equal(t, http.StatusNotFound, reports[0].StatusCode)
+		assert.Equal(t, "grpc", reports[1].PluginName)
+		assert.Equal(t, "plugin is nil or not initialized", reports[1].ErrorMessage)
+		assert.Equal(t, "http", reports[2].PluginName)
+		assert.Equal(t, SeverityPass, reports[2].Severity)
+	})
+
 	t.Run("Filtered_Error", func(t *testing.T) {
 		registry := map[string]Readiness{
 			"http": &mockReadiness{name: "http", err: errors.New("not ready")},
@@ -613,6 +836,75 @@ func TestReadyHandler(t *testing.T) {
 		require.Len(t, reports, 1)
 		assert.Equal(t, http.StatusInternalServerError, reports[0].StatusCode)
 	})
+
+	// ---- Check deadline and panic isolation ----
+
+	t.Run("CheckTimeout", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", block: newBlock(t)},
+			"grpc": &mockReadiness{name: "grpc", st: &apiStatus.Status{Code: 200}},
+		}
+		h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithCheckTimeout(time.Millisecond*20))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http&plugin=grpc", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		require.Len(t, reports, 2)
+		assert.Equal(t, http.StatusGatewayTimeout, reports["http"].StatusCode)
+		assert.Equal(t, 200, reports["grpc"].StatusCode)
+	})
+
+	t.Run("CheckPanic", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", panicVal: "nil map"},
+			"grpc": &mockReadiness{name: "grpc", st: &apiStatus.Status{Code: 200}},
+		}
+		h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		require.Len(t, reports, 2)
+		assert.Equal(t, "check panicked: nil map", reports["http"].ErrorMessage)
+		assert.Equal(t, 200, reports["grpc"].StatusCode)
+	})
+
+	// ---- Critical and non-critical plugins ----
+
+	t.Run("NonCriticalFailure", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+			"kv":   &mockReadiness{name: "kv", err: errors.New("not ready")},
+		}
+		h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithNonCriticalPlugins("kv"))
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http&plugin=kv", nil)
+		h.ServeHTTP(rec, req)
+
+		// the filtered path fails on an error, but not for a non-critical plugin
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, SeverityWarn, rec.Header().Get(HealthStatusHeader))
+		reports := reportsByName(parseReports(t, rec.Body.Bytes()))
+		assert.Equal(t, SeverityWarn, reports["kv"].Severity)
+	})
+
+	t.Run("UnhealthyWithoutFailing", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", err: errors.New("not ready")},
+		}
+		h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil)
+		h.ServeHTTP(rec, req)
+
+		// the all-plugins path does not fail on an error, the report still warns
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, SeverityWarn, rec.Header().Get(HealthStatusHeader))
+	})
 }
 
 // --- Jobs Handler Tests ---
@@ -691,6 +983,48 @@ func TestJobsHandler(t *testing.T) {
 		assert.False(t, reports[1].Ready)
 		assert.Equal(t, "paused", reports[1].ErrorMessage)
 	})
+
+	t.Run("MultipleProviders", func(t *testing.T) {
+		registry := jobsRegistry(
+			&mockJobsChecker{name: "temporal", states: []*jobsApi.State{{Pipeline: "workflows", Driver: "temporal", Ready: true}}},
+			&mockJobsChecker{name: "jobs", states: []*jobsApi.State{{Pipeline: "pipe1", Driver: "memory", Ready: true}}},
+		)
+		h := NewMultiJobsHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil)
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		reports := parseJobsReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 2)
+
+		// ordered by provider name
+		assert.Equal(t, "jobs", reports[0].Source)
+		assert.Equal(t, "pipe1", reports[0].Pipeline)
+		assert.Equal(t, "temporal", reports[1].Source)
+		assert.Equal(t, "workflows", reports[1].Pipeline)
+	})
+
+	t.Run("FailedProvider", func(t *testing.T) {
+		registry := jobsRegistry(
+			&mockJobsChecker{name: "temporal", err: errors.New("connection refused")},
+			&mockJobsChecker{name: "jobs", states: []*jobsApi.State{{Pipeline: "pipe1", Driver: "memory", Ready: true}}},
+		)
+		h := NewMultiJobsHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil)
+		h.ServeHTTP(rec, req)
+
+		// the other providers are still reported
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := parseJobsReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 2)
+		assert.Equal(t, "pipe1", reports[0].Pipeline)
+		assert.Equal(t, "temporal", reports[1].Source)
+		assert.Empty(t, reports[1].Pipeline)
+		assert.Equal(t, "connection refused", reports[1].ErrorMessage)
+		assert.NotEmpty(t, reports[1].Violations)
+	})
 }
 
 // --- Response Write Failures ---
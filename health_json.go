@@ -0,0 +1,90 @@
+package status
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HealthJSONContentType is the media type of the "Health Check Response Format
+// for HTTP APIs" draft, served by /health and /ready to the requests accepting
+// it.
+const HealthJSONContentType = "application/health+json"
+
+// HealthJSON is a /health or /ready response in the health+json format.
+type HealthJSON struct {
+	// Status is SeverityPass, SeverityWarn or SeverityFail.
+	Status    string `json:"status"`
+	ServiceID string `json:"serviceId,omitempty"`
+	// Output lists the errors of the unhealthy plugins, empty on pass.
+	Output string    `json:"output,omitempty"`
+	Time   time.Time `json:"time"`
+	// Checks holds a single check of each plugin, keyed by plugin name.
+	Checks map[string][]HealthJSONCheck `json:"checks"`
+}
+
+// HealthJSONCheck is the check of a single plugin in a HealthJSON document. The
+// observed value is the status code of the plugin.
+type HealthJSONCheck struct {
+	ComponentType string    `json:"componentType"`
+	ObservedValue int       `json:"observedValue"`
+	ObservedUnit  string    `json:"observedUnit"`
+	Status        string    `json:"status"`
+	Time          time.Time `json:"time,omitzero"`
+	Output        string    `json:"output,omitempty"`
+}
+
+// acceptsHealthJSON reports whether r accepts the health+json format. Any other
+// or missing Accept header gets the report array.
+func acceptsHealthJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for part := range strings.SplitSeq(accept, ",") {
+			mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || !strings.EqualFold(mt, HealthJSONContentType) {
+				continue
+			}
+
+			// q=0 means not acceptable
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// healthJSON converts the evaluated reports and their overall status into a
+// health+json document.
+func healthJSON(report []*Report, overall, serviceID string) *HealthJSON {
+	doc := &HealthJSON{
+		Status:    overall,
+		ServiceID: serviceID,
+		Time:      time.Now(),
+		Checks:    make(map[string][]HealthJSONCheck, len(report)),
+	}
+
+	var outputs []string
+	for _, rep := range report {
+		doc.Checks[rep.PluginName] = []HealthJSONCheck{{
+			ComponentType: "component",
+			ObservedValue: rep.StatusCode,
+			ObservedUnit:  "status_code",
+			Status:        rep.Severity,
+			Time:          rep.CheckedAt,
+			Output:        rep.ErrorMessage,
+		}}
+
+		if rep.Severity != SeverityPass && rep.ErrorMessage != "" {
+			outputs = append(outputs, rep.PluginName+": "+rep.ErrorMessage)
+		}
+	}
+
+	doc.Output = strings.Join(outputs, "; ")
+
+	return doc
+}
@@ -0,0 +1,78 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptsHealthJSON(t *testing.T) {
+	for _, tt := range []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: false},
+		{accept: "application/json", want: false},
+		{accept: "application/health+json", want: true},
+		{accept: "Application/Health+JSON; charset=utf-8", want: true},
+		{accept: "application/json;q=0.9, application/health+json", want: true},
+		{accept: "application/health+json;q=0", want: false},
+		{accept: "*/*", want: false},
+	} {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+
+		assert.Equal(t, tt.want, acceptsHealthJSON(req), tt.accept)
+	}
+}
+
+func TestHealthJSONResponse(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	registry := map[string]Readiness{
+		"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}},
+		"grpc": &mockReadiness{name: "grpc", err: errors.New("no workers")},
+	}
+	h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithServiceID("orders"))
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http&plugin=grpc", nil)
+	req.Header.Set("Accept", HealthJSONContentType)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, HealthJSONContentType, rec.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+
+	var doc HealthJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, SeverityFail, doc.Status)
+	assert.Equal(t, "orders", doc.ServiceID)
+	assert.Equal(t, "grpc: no workers", doc.Output)
+	assert.False(t, doc.Time.IsZero())
+	require.Len(t, doc.Checks, 2)
+
+	require.Len(t, doc.Checks["http"], 1)
+	assert.Equal(t, SeverityPass, doc.Checks["http"][0].Status)
+	assert.Equal(t, http.StatusOK, doc.Checks["http"][0].ObservedValue)
+	assert.Empty(t, doc.Checks["http"][0].Output)
+
+	require.Len(t, doc.Checks["grpc"], 1)
+	assert.Equal(t, SeverityFail, doc.Checks["grpc"][0].Status)
+	assert.Equal(t, "no workers", doc.Checks["grpc"][0].Output)
+
+	// the report array stays the default
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http", nil))
+	assert.NotEqual(t, HealthJSONContentType, rec.Header().Get("Content-Type"))
+	require.Len(t, parseReports(t, rec.Body.Bytes()), 1)
+}
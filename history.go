@@ -0,0 +1,185 @@
+package status
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// HistoryEntry is a single recorded check result of a plugin.
+type HistoryEntry struct {
+	PluginName   string    `json:"plugin_name"`
+	StatusCode   int       `json:"status_code"`
+	ErrorMessage string    `json:"error_message"`
+	Severity     string    `json:"severity"`
+	CheckedAt    time.Time `json:"checked_at"`
+	// Transition is set on the first entry after the severity of the plugin
+	// changed, e.g. "pass->fail".
+	Transition string `json:"transition,omitempty"`
+}
+
+// ring is a fixed size buffer of the latest entries of one plugin.
+type ring struct {
+	entries []HistoryEntry
+	// index of the next write
+	next int
+	full bool
+	// severity of the last entry, including the ones already overwritten
+	lastSeverity string
+}
+
+func (r *ring) push(e HistoryEntry) {
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the entries from the oldest to the newest.
+func (r *ring) list() []HistoryEntry {
+	if !r.full {
+		return slices.Clone(r.entries[:r.next])
+	}
+
+	return slices.Concat(r.entries[r.next:], r.entries[:r.next])
+}
+
+// last returns the newest entry, nil if there is none.
+func (r *ring) last() *HistoryEntry {
+	if !r.full && r.next == 0 {
+		return nil
+	}
+
+	return &r.entries[(r.next-1+len(r.entries))%len(r.entries)]
+}
+
+// history keeps the latest check results and severity transitions of every
+// plugin of one probe type, at most size entries per plugin.
+type history struct {
+	mu    sync.Mutex
+	size  int
+	rings map[string]*ring
+}
+
+func newHistory(size int) *history {
+	return &history{
+		size:  size,
+		rings: make(map[string]*ring),
+	}
+}
+
+// latest returns the newest entry of the plugin, false if it has none. A nil
+// history has none.
+func (h *history) latest(name string) (HistoryEntry, bool) {
+	if h == nil {
+		return HistoryEntry{}, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rings[name]
+	if !ok {
+		return HistoryEntry{}, false
+	}
+
+	last := r.last()
+	if last == nil {
+		return HistoryEntry{}, false
+	}
+
+	return *last, true
+}
+
+// record adds the reports carrying a check result to the history. A cached
+// result served again is recorded once.
+func (h *history) record(report []*Report) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, rep := range report {
+		if rep.CheckedAt.IsZero() {
+			continue
+		}
+
+		r, ok := h.rings[rep.PluginName]
+		if !ok {
+			r = &ring{entries: make([]HistoryEntry, h.size)}
+			h.rings[rep.PluginName] = r
+		}
+
+		if last := r.last(); last != nil && last.CheckedAt.Equal(rep.CheckedAt) {
+			continue
+		}
+
+		e := HistoryEntry{
+			PluginName:   rep.PluginName,
+			StatusCode:   rep.StatusCode,
+			ErrorMessage: rep.ErrorMessage,
+			Severity:     rep.Severity,
+			CheckedAt:    rep.CheckedAt,
+		}
+
+		if r.lastSeverity != "" && r.lastSeverity != rep.Severity {
+			e.Transition = r.lastSeverity + "->" + rep.Severity
+		}
+		r.lastSeverity = rep.Severity
+
+		r.push(e)
+	}
+}
+
+// entries returns the history of the named plugins, or of all plugins if names
+// is empty, ordered by check time.
+func (h *history) entries(names []string) []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var out []HistoryEntry
+	for name, r := range h.rings {
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+
+		out = append(out, r.list()...)
+	}
+
+	slices.SortStableFunc(out, func(a, b HistoryEntry) int {
+		return a.CheckedAt.Compare(b.CheckedAt)
+	})
+
+	return out
+}
+
+// historyHandler serves the history of one probe type, optionally filtered
+// with the ?plugin= query parameter.
+type historyHandler struct {
+	log     *slog.Logger
+	history *history
+}
+
+func (hh *historyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	entries := hh.history.entries(r.URL.Query()[pluginsQuery])
+	if entries == nil {
+		entries = []HistoryEntry{}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		hh.log.Error("failed to marshal history", "error", err)
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		hh.log.Error("failed to write history", "error", err)
+	}
+}
@@ -0,0 +1,143 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3)
+	start := time.Now()
+
+	// severities of five consecutive checks of the http plugin
+	for i, sev := range []string{SeverityPass, SeverityPass, SeverityFail, SeverityFail, SeverityPass} {
+		h.record([]*Report{{PluginName: "http", StatusCode: http.StatusOK, Severity: sev, CheckedAt: start.Add(time.Duration(i) * time.Second)}})
+	}
+
+	entries := h.entries(nil)
+	require.Len(t, entries, 3)
+	// the oldest two entries were overwritten, the transitions are kept
+	assert.Equal(t, start.Add(time.Second*2), entries[0].CheckedAt)
+	assert.Equal(t, "pass->fail", entries[0].Transition)
+	assert.Empty(t, entries[1].Transition)
+	assert.Equal(t, "fail->pass", entries[2].Transition)
+
+	t.Run("SameResultRecordedOnce", func(t *testing.T) {
+		h := newHistory(3)
+		rep := &Report{PluginName: "http", Severity: SeverityPass, CheckedAt: time.Now()}
+		h.record([]*Report{rep})
+		h.record([]*Report{rep})
+
+		assert.Len(t, h.entries(nil), 1)
+	})
+
+	t.Run("WithoutCheckResult", func(t *testing.T) {
+		h := newHistory(3)
+		h.record([]*Report{{PluginName: "kv", StatusCode: http.StatusNotFound}})
+
+		assert.Empty(t, h.entries(nil))
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		h := newHistory(3)
+		h.record([]*Report{
+			{PluginName: "http", Severity: SeverityPass, CheckedAt: start},
+			{PluginName: "grpc", Severity: SeverityPass, CheckedAt: start.Add(time.Second)},
+		})
+
+		entries := h.entries([]string{"grpc"})
+		require.Len(t, entries, 1)
+		assert.Equal(t, "grpc", entries[0].PluginName)
+		assert.Len(t, h.entries(nil), 2)
+	})
+}
+
+func TestHistoryHandler(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	h := newHistory(10)
+	hh := &historyHandler{log: log, history: h}
+
+	rec := httptest.NewRecorder()
+	hh.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health/history", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+
+	cr := map[string]Checker{
+		"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+		"grpc": &mockChecker{name: "grpc", st: &apiStatus.Status{Code: 500}},
+	}
+	health := NewHealthHandler(cr, newShutdownPtr(false), log, http.StatusServiceUnavailable, withHistory(h))
+	health.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil))
+
+	rec = httptest.NewRecorder()
+	hh.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health/history?plugin=grpc", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var entries []HistoryEntry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, "grpc", entries[0].PluginName)
+	assert.Equal(t, http.StatusServiceUnavailable, entries[0].StatusCode)
+	assert.Equal(t, SeverityFail, entries[0].Severity)
+	assert.False(t, entries[0].CheckedAt.IsZero())
+}
+
+// TestHistoryCoalesced checks that a result shared within the result TTL is
+// recorded once, however many requests it is served to.
+func TestHistoryCoalesced(t *testing.T) {
+	var calls atomic.Int32
+	h := newHistory(10)
+	health := NewHealthHandler(map[string]Checker{"http": &countingChecker{calls: &calls, st: &apiStatus.Status{Code: 500}}}, newShutdownPtr(false),
+		slog.New(slog.DiscardHandler), http.StatusServiceUnavailable, withCoalescer(newCoalescer(time.Minute)), withHistory(h))
+
+	var checkedAt time.Time
+	for range 3 {
+		rec := httptest.NewRecorder()
+		health.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil))
+
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		if checkedAt.IsZero() {
+			checkedAt = reports[0].CheckedAt
+		}
+		assert.True(t, checkedAt.Equal(reports[0].CheckedAt))
+	}
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Len(t, h.entries(nil), 1)
+}
+
+func TestHistoryRPC(t *testing.T) {
+	p := &Plugin{
+		log:           slog.New(slog.DiscardHandler),
+		healthHistory: newHistory(10),
+		readyHistory:  newHistory(10),
+	}
+	p.readyHistory.record([]*Report{{PluginName: "http", Severity: SeverityPass, CheckedAt: time.Now()}})
+
+	r := &rpc{srv: p, log: p.log}
+
+	out := &HistoryResponse{}
+	require.NoError(t, r.History(&HistoryRequest{Probe: "ready"}, out))
+	require.Len(t, out.Entries, 1)
+	assert.Equal(t, "http", out.Entries[0].PluginName)
+
+	out = &HistoryResponse{}
+	require.NoError(t, r.History(&HistoryRequest{Probe: "health"}, out))
+	assert.Empty(t, out.Entries)
+
+	// errors.E does not unwrap, the sentinel is pinned on the lookup itself
+	_, err := p.history("jobs", nil)
+	require.ErrorIs(t, err, errUnknownProbe)
+	require.ErrorContains(t, r.History(&HistoryRequest{Probe: "jobs"}, &HistoryResponse{}), errUnknownProbe.Error())
+}
@@ -1,71 +1,272 @@
 package status
 
 import (
+	"context"
 	"encoding/json"
+	stderr "errors"
+	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
 )
 
 type Jobs struct {
-	statusJobsRegistry    JobsChecker
+	statusJobsRegistry    map[string]JobsChecker
 	unavailableStatusCode int
 	log                   *slog.Logger
 	shutdownInitiated     *atomic.Bool
+	opts                  handlerOptions
+}
+
+// NewJobsHandler returns the /jobs handler of a single JobsChecker. A nil jc
+// reports the jobs plugin as not found.
+func NewJobsHandler(jc JobsChecker, shutdownInitiated *atomic.Bool, log *slog.Logger, usc int, opts ...HandlerOption) *Jobs {
+	var registry map[string]JobsChecker
+	if jc != nil {
+		registry = map[string]JobsChecker{jc.Name(): jc}
+	}
+
+	return NewMultiJobsHandler(registry, shutdownInitiated, log, usc, opts...)
 }
 
-func NewJobsHandler(jc JobsChecker, shutdownInitiated *atomic.Bool, log *slog.Logger, usc int) *Jobs {
+// NewMultiJobsHandler returns the /jobs handler of every JobsChecker in
+// registry, keyed by plugin name, which labels their pipelines as their source.
+func NewMultiJobsHandler(registry map[string]JobsChecker, shutdownInitiated *atomic.Bool, log *slog.Logger, usc int, opts ...HandlerOption) *Jobs {
 	return &Jobs{
-		statusJobsRegistry:    jc,
+		statusJobsRegistry:    registry,
 		unavailableStatusCode: usc,
 		log:                   log,
 		shutdownInitiated:     shutdownInitiated,
+		opts:                  newHandlerOptions(opts),
 	}
 }
 
 func (jb *Jobs) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if jb.shutdownInitiated != nil && jb.shutdownInitiated.Load() {
-		http.Error(w, "service is shutting down", jb.unavailableStatusCode)
+		jb.opts.writeShutdown(w, r, jb.log, jb.unavailableStatusCode)
+		return
+	}
+
+	if st := jb.opts.maintenance.state(); st.On {
+		jb.opts.writeMaintenance(w, r, jb.log, jb.unavailableStatusCode, st)
 		return
 	}
 
-	if jb.statusJobsRegistry == nil {
+	if len(jb.statusJobsRegistry) == 0 {
 		http.Error(w, "jobs plugin not found", jb.unavailableStatusCode)
 		return
 	}
 
-	jobStates, err := jb.statusJobsRegistry.JobsState(r.Context())
+	jq, err := parseJobsQuery(r.URL.Query())
 	if err != nil {
-		jb.log.Error("jobs state", "error", err)
-		http.Error(w, "jobs plugin not found", jb.unavailableStatusCode)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	report := make([]*JobsReport, 0, len(jobStates))
-
-	// write info about underlying drivers
-	for _, js := range jobStates {
-		report = append(report, &JobsReport{
-			Pipeline:     js.Pipeline,
-			Priority:     js.Priority,
-			Ready:        js.Ready,
-			Queue:        js.Queue,
-			Active:       js.Active,
-			Delayed:      js.Delayed,
-			Reserved:     js.Reserved,
-			Driver:       js.Driver,
-			ErrorMessage: js.ErrorMessage,
-		})
+	results, checkedAt, err := jb.jobsState(r)
+	if err != nil {
+		http.Error(w, err.Error(), jb.unavailableStatusCode)
+		return
+	}
+
+	err = jobsErr(results)
+	if err != nil {
+		jb.log.Error("jobs state", "error", err)
+
+		// a provider that failed is reported as such, unless all of them did
+		if !slices.ContainsFunc(results, func(res jobsResult) bool { return res.err == nil }) {
+			http.Error(w, "jobs plugin not found", jb.unavailableStatusCode)
+			return
+		}
 	}
 
-	data, err := json.Marshal(report)
+	report := jobsReports(results, checkedAt, jb.opts.jobsRules)
+	jb.opts.sampler.annotate(report)
+	jb.opts.stuck.observe(results, checkedAt)
+	jb.opts.stuck.annotate(report)
+
+	// /jobs/{pipeline}
+	if name := r.PathValue(pipelinePath); name != "" {
+		var matches []*JobsReport
+		for _, rep := range report {
+			if rep.Pipeline == name && jq.matchSource(rep) {
+				matches = append(matches, rep)
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			http.Error(w, "pipeline not found", http.StatusNotFound)
+		case 1:
+			jb.write(w, matches[0], len(matches[0].Violations) > 0)
+		default:
+			// pipelines of the same name reported by several plugins
+			sources := make([]string, 0, len(matches))
+			for _, rep := range matches {
+				sources = append(sources, rep.Source)
+			}
+
+			http.Error(w, fmt.Sprintf("pipeline %q is reported by %s, select one with ?%s=", name, strings.Join(sources, ", "), sourceQuery), http.StatusConflict)
+		}
+
+		return
+	}
+
+	// the status code covers every selected pipeline, not only the page
+	failing := slices.ContainsFunc(report, func(rep *JobsReport) bool { return len(rep.Violations) > 0 && jq.match(rep) })
+
+	page, total := jq.apply(report)
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+
+	jb.write(w, page, failing)
+}
+
+// write writes v, with the unavailable status code if failing.
+func (jb *Jobs) write(w http.ResponseWriter, v any, failing bool) {
+	data, err := json.Marshal(v)
 	if err != nil {
 		jb.log.Error("failed to marshal jobs state report", "error", err)
 		return
 	}
 
+	if failing {
+		w.WriteHeader(jb.unavailableStatusCode)
+	}
+
 	_, err = w.Write(data)
 	if err != nil {
 		jb.log.Error("failed to write jobs state report", "error", err)
 	}
 }
+
+// jobsResult is the JobsState result of a single JobsChecker.
+type jobsResult struct {
+	source string
+	states []*jobsApi.State
+	err    error
+}
+
+// collectJobs calls every JobsChecker concurrently, each under timeout if set,
+// and returns their results ordered by name.
+func collectJobs(ctx context.Context, registry map[string]JobsChecker, timeout time.Duration) []jobsResult {
+	results := make([]jobsResult, 0, len(registry))
+	for _, name := range slices.Sorted(maps.Keys(registry)) {
+		if registry[name] != nil {
+			results = append(results, jobsResult{source: name})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		jc := registry[results[i].source]
+
+		wg.Go(func() {
+			jctx, end := traceJobs(ctx, results[i].source)
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				jctx, cancel = context.WithTimeout(jctx, timeout)
+				defer cancel()
+			}
+
+			results[i].states, results[i].err = jc.JobsState(jctx)
+			end(len(results[i].states), results[i].err)
+		})
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// jobsErr joins the errors of the failed providers, nil if none failed.
+func jobsErr(results []jobsResult) error {
+	var errs []error
+	for _, res := range results {
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.source, res.err))
+		}
+	}
+
+	return stderr.Join(errs...)
+}
+
+// jobsReports returns a report of every pipeline state, labeled with the
+// provider that reported it and with the violations of the rules, or a single
+// report of a failed provider. The required pipelines no provider reported are
+// reported last, without a source.
+func jobsReports(results []jobsResult, checkedAt time.Time, rules *jobsRules) []*JobsReport {
+	report := make([]*JobsReport, 0, len(results))
+	ageMs := time.Since(checkedAt).Milliseconds()
+	violations := rules.check(results)
+	reported := make(map[pipelineKey]struct{})
+
+	for _, res := range results {
+		if res.err != nil {
+			report = append(report, &JobsReport{
+				Source:       res.source,
+				ErrorMessage: res.err.Error(),
+				Violations:   []string{"plugin failed to report its pipelines"},
+				CheckedAt:    checkedAt,
+				AgeMs:        ageMs,
+			})
+			continue
+		}
+
+		// write info about underlying drivers
+		for _, js := range res.states {
+			key := pipelineKey{source: res.source, pipeline: js.Pipeline}
+			reported[key] = struct{}{}
+
+			report = append(report, &JobsReport{
+				Source:       res.source,
+				Pipeline:     js.Pipeline,
+				Priority:     js.Priority,
+				Ready:        js.Ready,
+				Queue:        js.Queue,
+				Active:       js.Active,
+				Delayed:      js.Delayed,
+				Reserved:     js.Reserved,
+				Driver:       js.Driver,
+				ErrorMessage: js.ErrorMessage,
+				Violations:   violations[key],
+				CheckedAt:    checkedAt,
+				AgeMs:        ageMs,
+			})
+		}
+	}
+
+	// required pipelines no jobs plugin reported
+	missing := slices.SortedFunc(maps.Keys(violations), pipelineKey.compare)
+	for _, key := range missing {
+		if _, ok := reported[key]; !ok {
+			report = append(report, &JobsReport{
+				Source:     key.source,
+				Pipeline:   key.pipeline,
+				Violations: violations[key],
+				CheckedAt:  checkedAt,
+				AgeMs:      ageMs,
+			})
+		}
+	}
+
+	return report
+}
+
+// jobsState returns the results of the providers and the time they were taken:
+// from the cache in polling mode, otherwise from the providers, each under the
+// check timeout.
+func (jb *Jobs) jobsState(r *http.Request) ([]jobsResult, time.Time, error) {
+	if jb.opts.jobsCache != nil {
+		return jb.opts.jobsCache.load()
+	}
+
+	return collectJobs(r.Context(), jb.statusJobsRegistry, jb.opts.checkTimeout), time.Now(), nil
+}
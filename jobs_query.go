@@ -0,0 +1,170 @@
+package status
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+const (
+	// TotalCountHeader carries the number of pipelines /jobs selected before
+	// the pagination.
+	TotalCountHeader = "X-Total-Count"
+
+	// name of the {pipeline} wildcard of /jobs/{pipeline}
+	pipelinePath = "pipeline"
+
+	pipelineQuery = "pipeline"
+	sourceQuery   = "source"
+	driverQuery   = "driver"
+	readyQuery    = "ready"
+	sortQuery     = "sort"
+	offsetQuery   = "offset"
+	limitQuery    = "limit"
+
+	// /jobs sort orders: the order of the jobs plugin is kept by default
+	sortPriority = "priority"
+	sortBacklog  = "backlog"
+	sortName     = "name"
+)
+
+// jobsQuery selects, orders and paginates the pipelines of /jobs.
+type jobsQuery struct {
+	pipelines []string
+	// names of the JobsChecker plugins that reported the pipelines
+	sources []string
+	drivers []string
+	// only the pipelines with this ready state, all if nil
+	ready *bool
+	sort  string
+	// offset of the first pipeline and max number of pipelines, 0 means all
+	offset int
+	limit  int
+}
+
+// parseJobsQuery reads the ?pipeline=, ?source=, ?driver=, ?ready=, ?sort=, ?offset= and
+// ?limit= query parameters.
+func parseJobsQuery(q url.Values) (jobsQuery, error) {
+	jq := jobsQuery{
+		pipelines: q[pipelineQuery],
+		sources:   q[sourceQuery],
+		drivers:   q[driverQuery],
+		sort:      q.Get(sortQuery),
+	}
+
+	if raw := q.Get(readyQuery); raw != "" {
+		ready, err := strconv.ParseBool(raw)
+		if err != nil {
+			return jobsQuery{}, fmt.Errorf("invalid ready %q: %w", raw, err)
+		}
+
+		jq.ready = &ready
+	}
+
+	var err error
+	jq.offset, err = nonNegative(q, offsetQuery)
+	if err != nil {
+		return jobsQuery{}, err
+	}
+
+	jq.limit, err = nonNegative(q, limitQuery)
+	if err != nil {
+		return jobsQuery{}, err
+	}
+
+	return jq, jq.validate()
+}
+
+// validate checks the sort order and the pagination.
+func (jq jobsQuery) validate() error {
+	switch jq.sort {
+	case "", sortPriority, sortBacklog, sortName:
+	default:
+		return fmt.Errorf("invalid sort %q: must be one of %s, %s or %s", jq.sort, sortPriority, sortBacklog, sortName)
+	}
+
+	if jq.offset < 0 || jq.limit < 0 {
+		return fmt.Errorf("invalid pagination: offset %d and limit %d must not be negative", jq.offset, jq.limit)
+	}
+
+	return nil
+}
+
+// nonNegative parses the named query parameter, 0 if absent.
+func nonNegative(q url.Values, name string) (int, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", name, raw)
+	}
+
+	return n, nil
+}
+
+// match reports whether the pipeline passes the filters.
+func (jq jobsQuery) match(rep *JobsReport) bool {
+	switch {
+	case len(jq.pipelines) > 0 && !slices.Contains(jq.pipelines, rep.Pipeline):
+		return false
+	case !jq.matchSource(rep):
+		return false
+	case len(jq.drivers) > 0 && !slices.Contains(jq.drivers, rep.Driver):
+		return false
+	case jq.ready != nil && rep.Ready != *jq.ready:
+		return false
+	default:
+		return true
+	}
+}
+
+// matchSource reports whether the pipeline was reported by a selected source.
+func (jq jobsQuery) matchSource(rep *JobsReport) bool {
+	return len(jq.sources) == 0 || slices.Contains(jq.sources, rep.Source)
+}
+
+// apply returns the page of the matching pipelines in the requested order, and
+// the number of matching pipelines.
+func (jq jobsQuery) apply(report []*JobsReport) ([]*JobsReport, int) {
+	selected := make([]*JobsReport, 0, len(report))
+	for _, rep := range report {
+		if jq.match(rep) {
+			selected = append(selected, rep)
+		}
+	}
+
+	switch jq.sort {
+	case sortPriority:
+		slices.SortStableFunc(selected, func(a, b *JobsReport) int {
+			return cmp.Or(cmp.Compare(a.Priority, b.Priority), cmp.Compare(a.Pipeline, b.Pipeline))
+		})
+	case sortBacklog:
+		// the largest backlog first
+		slices.SortStableFunc(selected, func(a, b *JobsReport) int {
+			return cmp.Or(cmp.Compare(b.backlog(), a.backlog()), cmp.Compare(a.Pipeline, b.Pipeline))
+		})
+	case sortName:
+		slices.SortStableFunc(selected, func(a, b *JobsReport) int {
+			return cmp.Compare(a.Pipeline, b.Pipeline)
+		})
+	}
+
+	total := len(selected)
+
+	selected = selected[min(jq.offset, total):]
+	if jq.limit > 0 && jq.limit < len(selected) {
+		selected = selected[:jq.limit]
+	}
+
+	return selected, total
+}
+
+// backlog is the number of jobs the pipeline has not finished yet.
+func (jr *JobsReport) backlog() int64 {
+	return jr.Active + jr.Delayed + jr.Reserved
+}
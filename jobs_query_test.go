@@ -0,0 +1,187 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pipelineNames(reports []*JobsReport) []string {
+	names := make([]string, 0, len(reports))
+	for _, rep := range reports {
+		names = append(names, rep.Pipeline)
+	}
+	return names
+}
+
+func TestJobsQuery(t *testing.T) {
+	report := []*JobsReport{
+		{Pipeline: "emails", Driver: "amqp", Priority: 10, Active: 5, Ready: true},
+		{Pipeline: "orders", Driver: "kafka", Priority: 1, Delayed: 20, Ready: false},
+		{Pipeline: "audit", Driver: "amqp", Priority: 10, Reserved: 1, Ready: true},
+		{Pipeline: "local", Driver: "memory", Priority: 5, Ready: false},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		query     string
+		want      []string
+		wantTotal int
+	}{
+		{"All", "", []string{"emails", "orders", "audit", "local"}, 4},
+		{"Pipeline", "pipeline=orders&pipeline=local", []string{"orders", "local"}, 2},
+		{"Driver", "driver=amqp", []string{"emails", "audit"}, 2},
+		{"NotReady", "ready=false", []string{"orders", "local"}, 2},
+		{"SortPriority", "sort=priority", []string{"orders", "local", "audit", "emails"}, 4},
+		{"SortBacklog", "sort=backlog", []string{"orders", "emails", "audit", "local"}, 4},
+		{"SortName", "sort=name", []string{"audit", "emails", "local", "orders"}, 4},
+		{"Page", "sort=name&offset=1&limit=2", []string{"emails", "local"}, 4},
+		{"PastTheEnd", "offset=10", []string{}, 4},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			jq, err := parseJobsQuery(q)
+			require.NoError(t, err)
+
+			page, total := jq.apply(report)
+			assert.Equal(t, tt.want, pipelineNames(page))
+			assert.Equal(t, tt.wantTotal, total)
+		})
+	}
+
+	for _, raw := range []string{"ready=maybe", "sort=size", "limit=-1", "offset=x"} {
+		q, err := url.ParseQuery(raw)
+		require.NoError(t, err)
+
+		_, err = parseJobsQuery(q)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestJobsHandlerQuery(t *testing.T) {
+	jc := &mockJobsChecker{states: []*jobsApi.State{
+		{Pipeline: "emails", Driver: "amqp", Ready: true},
+		{Pipeline: "orders", Driver: "kafka", Ready: false},
+	}}
+	h := NewJobsHandler(jc, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable,
+		WithJobsRules(map[string]JobsRule{"orders": {MustBeReady: true}}, nil))
+
+	mux := http.NewServeMux()
+	mux.Handle("/jobs", h)
+	mux.Handle("/jobs/{pipeline}", h)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, target, nil))
+		return rec
+	}
+
+	t.Run("Filtered", func(t *testing.T) {
+		rec := get("/jobs?driver=amqp")
+		// the broken rule of orders does not fail a view without it
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get(TotalCountHeader))
+		assert.Equal(t, []string{"emails"}, pipelineNames(parseJobsReports(t, rec.Body.Bytes())))
+
+		// but does fail a page without it
+		rec = get("/jobs?sort=name&limit=1")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get(TotalCountHeader))
+		assert.Equal(t, []string{"emails"}, pipelineNames(parseJobsReports(t, rec.Body.Bytes())))
+	})
+
+	t.Run("InvalidQuery", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, get("/jobs?sort=size").Code)
+	})
+
+	t.Run("Pipeline", func(t *testing.T) {
+		rec := get("/jobs/emails")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var rep JobsReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+		assert.Equal(t, "emails", rep.Pipeline)
+		assert.Equal(t, "amqp", rep.Driver)
+
+		rec = get("/jobs/orders")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+		assert.Equal(t, []string{"pipeline is not ready"}, rep.Violations)
+
+		assert.Equal(t, http.StatusNotFound, get("/jobs/unknown").Code)
+	})
+}
+
+func TestJobsHandlerSources(t *testing.T) {
+	registry := jobsRegistry(
+		&mockJobsChecker{name: "jobs", states: []*jobsApi.State{{Pipeline: "default", Driver: "amqp", Ready: true}}},
+		&mockJobsChecker{name: "temporal", states: []*jobsApi.State{
+			{Pipeline: "default", Driver: "temporal", Ready: true},
+			{Pipeline: "workflows", Driver: "temporal", Ready: true},
+		}},
+	)
+	h := NewMultiJobsHandler(registry, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable)
+
+	mux := http.NewServeMux()
+	mux.Handle("/jobs", h)
+	mux.Handle("/jobs/{pipeline}", h)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/jobs?source=temporal")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"default", "workflows"}, pipelineNames(parseJobsReports(t, rec.Body.Bytes())))
+
+	// a name reported by several plugins is ambiguous
+	rec = get("/jobs/default")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "jobs, temporal")
+
+	rec = get("/jobs/default?source=temporal")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var rep JobsReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rep))
+	assert.Equal(t, "temporal", rep.Source)
+	assert.Equal(t, "temporal", rep.Driver)
+
+	assert.Equal(t, http.StatusOK, get("/jobs/workflows").Code)
+	assert.Equal(t, http.StatusNotFound, get("/jobs/workflows?source=jobs").Code)
+}
+
+func TestJobsRPC(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true}, initLogger{}))
+	r := &rpc{srv: p, log: p.log}
+
+	// errors.E does not unwrap, the sentinel is pinned on the lookup itself
+	_, _, err := p.jobs(jobsQuery{})
+	require.ErrorIs(t, err, errJobsNotFound)
+	require.ErrorContains(t, r.Jobs(&JobsRequest{}, &JobsResponse{}), errJobsNotFound.Error())
+
+	p.statusJobsRegistry["jobs"] = &mockJobsChecker{states: []*jobsApi.State{
+		{Pipeline: "emails", Driver: "amqp", Ready: true},
+		{Pipeline: "orders", Driver: "kafka", Ready: false},
+	}}
+
+	out := &JobsResponse{}
+	require.NoError(t, r.Jobs(&JobsRequest{Ready: new(false)}, out))
+	assert.Equal(t, 1, out.Total)
+	assert.Equal(t, []string{"orders"}, pipelineNames(out.Reports))
+
+	require.Error(t, r.Jobs(&JobsRequest{Sort: "size"}, &JobsResponse{}))
+}
@@ -0,0 +1,141 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	"github.com/roadrunner-server/api-plugins/v6/status"
+)
+
+// jobsReadinessName is the name /ready reports the job pipeline rules under.
+const jobsReadinessName = "jobs_pipelines"
+
+// JobsRule is a readiness rule of a job pipeline, configured per pipeline or per
+// driver. A pipeline breaking its rule fails /jobs.
+type JobsRule struct {
+	// The pipeline must be reported by one of the jobs plugins. Only for rules
+	// keyed by pipeline.
+	Required bool `mapstructure:"required"`
+	// The pipeline must report ready and no error.
+	MustBeReady bool `mapstructure:"must_be_ready"`
+	// Max number of active, delayed and reserved jobs, 0 means no limit.
+	MaxActive   int64 `mapstructure:"max_active"`
+	MaxDelayed  int64 `mapstructure:"max_delayed"`
+	MaxReserved int64 `mapstructure:"max_reserved"`
+}
+
+// jobsRules holds the rules by pipeline and by driver; the rule of a pipeline
+// takes precedence over the one of its driver.
+type jobsRules struct {
+	pipelines map[string]JobsRule
+	drivers   map[string]JobsRule
+}
+
+// check returns the violations of every pipeline breaking its rule, keyed by
+// provider and pipeline, including the required pipelines no provider reported,
+// which are keyed without a source.
+func (jr *jobsRules) check(results []jobsResult) map[pipelineKey][]string {
+	violations := make(map[pipelineKey][]string)
+	if jr == nil {
+		return violations
+	}
+
+	for _, res := range results {
+		for _, st := range res.states {
+			if v := jr.violations(st); len(v) > 0 {
+				violations[pipelineKey{source: res.source, pipeline: st.Pipeline}] = v
+			}
+		}
+	}
+
+	for name, rule := range jr.pipelines {
+		if !rule.Required {
+			continue
+		}
+
+		reported := slices.ContainsFunc(results, func(res jobsResult) bool {
+			return slices.ContainsFunc(res.states, func(st *jobsApi.State) bool { return st.Pipeline == name })
+		})
+		if !reported {
+			violations[pipelineKey{pipeline: name}] = []string{"pipeline is not reported by the jobs plugin"}
+		}
+	}
+
+	return violations
+}
+
+// violations returns the ways st breaks its rule, none if it has no rule.
+func (jr *jobsRules) violations(st *jobsApi.State) []string {
+	rule, ok := jr.pipelines[st.Pipeline]
+	if !ok {
+		rule, ok = jr.drivers[st.Driver]
+	}
+	if !ok {
+		return nil
+	}
+
+	var v []string
+	if rule.MustBeReady && !st.Ready {
+		v = append(v, "pipeline is not ready")
+	}
+	if rule.MustBeReady && st.ErrorMessage != "" {
+		v = append(v, "pipeline reported an error: "+st.ErrorMessage)
+	}
+	if rule.MaxActive > 0 && st.Active > rule.MaxActive {
+		v = append(v, fmt.Sprintf("%d active jobs exceed the maximum of %d", st.Active, rule.MaxActive))
+	}
+	if rule.MaxDelayed > 0 && st.Delayed > rule.MaxDelayed {
+		v = append(v, fmt.Sprintf("%d delayed jobs exceed the maximum of %d", st.Delayed, rule.MaxDelayed))
+	}
+	if rule.MaxReserved > 0 && st.Reserved > rule.MaxReserved {
+		v = append(v, fmt.Sprintf("%d reserved jobs exceed the maximum of %d", st.Reserved, rule.MaxReserved))
+	}
+
+	return v
+}
+
+// jobsReadiness is a Readiness failing while a job pipeline breaks its rule or
+// is stuck. It is registered under jobsReadinessName when the rules or the stuck
+// detection feed /ready, so a worker whose consumers are stuck leaves the
+// rotation.
+type jobsReadiness struct {
+	log      *slog.Logger
+	registry map[string]JobsChecker
+	// either may be nil
+	rules   *jobsRules
+	stuck   *stuckDetector
+	timeout time.Duration
+}
+
+func (jr *jobsReadiness) Ready() (*status.Status, error) {
+	results := collectJobs(context.Background(), jr.registry, jr.timeout)
+	jr.stuck.observe(results, time.Now())
+
+	err := jobsErr(results)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := jr.rules.check(results)
+	if len(violations) > 0 {
+		jr.log.Warn("job pipelines break their readiness rules", "violations", violations)
+		return &status.Status{Code: http.StatusServiceUnavailable}, nil
+	}
+
+	stuck := jr.stuck.stuck()
+	if len(stuck) > 0 {
+		jr.log.Warn("job pipelines are stuck", "stuck", stuck)
+		return &status.Status{Code: http.StatusServiceUnavailable}, nil
+	}
+
+	return &status.Status{Code: http.StatusOK}, nil
+}
+
+func (jr *jobsReadiness) Name() string {
+	return jobsReadinessName
+}
@@ -0,0 +1,147 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobsRules(t *testing.T) {
+	jr := &jobsRules{
+		pipelines: map[string]JobsRule{
+			"emails":  {Required: true, MaxActive: 10},
+			"reports": {Required: true},
+		},
+		drivers: map[string]JobsRule{
+			"amqp": {MustBeReady: true, MaxDelayed: 5},
+		},
+	}
+
+	violations := jr.check([]jobsResult{{source: "jobs", states: []*jobsApi.State{
+		// the pipeline rule takes precedence over the driver one
+		{Pipeline: "emails", Driver: "amqp", Active: 11, Ready: false},
+		{Pipeline: "orders", Driver: "amqp", Ready: false, ErrorMessage: "channel closed", Delayed: 6},
+		{Pipeline: "local", Driver: "memory", Active: 1000},
+	}}})
+
+	assert.Equal(t, map[pipelineKey][]string{
+		{source: "jobs", pipeline: "emails"}: {"11 active jobs exceed the maximum of 10"},
+		{source: "jobs", pipeline: "orders"}: {
+			"pipeline is not ready",
+			"pipeline reported an error: channel closed",
+			"6 delayed jobs exceed the maximum of 5",
+		},
+		{pipeline: "reports"}: {"pipeline is not reported by the jobs plugin"},
+	}, violations)
+
+	var none *jobsRules
+	assert.Empty(t, none.check([]jobsResult{{source: "jobs", states: []*jobsApi.State{{Pipeline: "emails"}}}}))
+}
+
+func TestJobsRulesSources(t *testing.T) {
+	jr := &jobsRules{pipelines: map[string]JobsRule{
+		"default": {Required: true, MustBeReady: true},
+		"emails":  {Required: true},
+		"reports": {Required: true},
+	}}
+	results := []jobsResult{
+		{source: "jobs", states: []*jobsApi.State{{Pipeline: "default", Ready: false}, {Pipeline: "emails", Ready: true}}},
+		// a required pipeline is satisfied by any provider
+		{source: "temporal", states: []*jobsApi.State{{Pipeline: "default", Ready: true}}},
+		{source: "broken", err: errors.New("connection refused")},
+	}
+
+	assert.Equal(t, map[pipelineKey][]string{
+		{source: "jobs", pipeline: "default"}: {"pipeline is not ready"},
+		{pipeline: "reports"}:                 {"pipeline is not reported by the jobs plugin"},
+	}, jr.check(results))
+
+	reports := jobsReports(results, time.Now(), jr)
+	require.Len(t, reports, 5)
+	assert.Equal(t, "jobs", reports[0].Source)
+	assert.Equal(t, []string{"pipeline is not ready"}, reports[0].Violations)
+	assert.Empty(t, reports[1].Violations)
+	assert.Equal(t, "temporal", reports[2].Source)
+	assert.Empty(t, reports[2].Violations)
+	assert.Equal(t, "broken", reports[3].Source)
+	// the missing required pipeline belongs to no provider
+	assert.Empty(t, reports[4].Source)
+	assert.Equal(t, "reports", reports[4].Pipeline)
+	assert.Equal(t, []string{"pipeline is not reported by the jobs plugin"}, reports[4].Violations)
+}
+
+func TestJobsHandlerRules(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	rules := WithJobsRules(map[string]JobsRule{
+		"emails":  {MustBeReady: true},
+		"reports": {Required: true},
+	}, nil)
+
+	serve := func(jc *mockJobsChecker) *httptest.ResponseRecorder {
+		h := NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable, rules)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil))
+		return rec
+	}
+
+	rec := serve(&mockJobsChecker{states: []*jobsApi.State{
+		{Pipeline: "emails", Ready: true},
+		{Pipeline: "reports", Ready: true},
+	}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	for _, rep := range parseJobsReports(t, rec.Body.Bytes()) {
+		assert.Empty(t, rep.Violations)
+	}
+
+	rec = serve(&mockJobsChecker{states: []*jobsApi.State{{Pipeline: "emails", Ready: false}}})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	reports := parseJobsReports(t, rec.Body.Bytes())
+	require.Len(t, reports, 2)
+	assert.Equal(t, "emails", reports[0].Pipeline)
+	assert.Equal(t, []string{"pipeline is not ready"}, reports[0].Violations)
+	// the missing required pipeline is reported after the ones of the jobs plugin
+	assert.Equal(t, "reports", reports[1].Pipeline)
+	assert.Equal(t, []string{"pipeline is not reported by the jobs plugin"}, reports[1].Violations)
+}
+
+func TestJobsReadiness(t *testing.T) {
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "emails", Ready: true}}}
+	jr := &jobsReadiness{
+		log:      slog.New(slog.DiscardHandler),
+		registry: jobsRegistry(jc),
+		rules:    &jobsRules{pipelines: map[string]JobsRule{"emails": {MustBeReady: true}}},
+		timeout:  time.Second,
+	}
+
+	st, err := jr.Ready()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, st.Code)
+
+	jc.states[0].Ready = false
+	st, err = jr.Ready()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, st.Code)
+
+	jc.err = errors.New("jobs plugin is stopped")
+	_, err = jr.Ready()
+	require.Error(t, err)
+
+	// fed into /ready, the broken rule takes the pod out of the rotation
+	jc.err = nil
+	h := NewReadyHandler(map[string]Readiness{jobsReadinessName: jr}, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	reports := parseReports(t, rec.Body.Bytes())
+	require.Len(t, reports, 1)
+	assert.Equal(t, jobsReadinessName, reports[0].PluginName)
+}
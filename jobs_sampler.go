@@ -0,0 +1,282 @@
+package status
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// backlog trends of a sampled pipeline
+	trendGrowing   = "growing"
+	trendShrinking = "shrinking"
+	trendSteady    = "steady"
+)
+
+// JobsSample is a single sampled state of a job pipeline, as served by
+// /jobs/history.
+type JobsSample struct {
+	Source    string    `json:"source"`
+	Pipeline  string    `json:"pipeline"`
+	SampledAt time.Time `json:"sampled_at"`
+	Active    int64     `json:"active"`
+	Delayed   int64     `json:"delayed"`
+	Reserved  int64     `json:"reserved"`
+}
+
+// JobsTrend is derived from the samples of a pipeline, oldest to newest.
+type JobsTrend struct {
+	// change per second over the sampled window
+	ActiveRate  float64 `json:"active_rate"`
+	DelayedRate float64 `json:"delayed_rate"`
+	BacklogRate float64 `json:"backlog_rate"`
+	// "growing", "shrinking" or "steady"
+	Backlog string `json:"backlog"`
+	// time to drain the backlog at the current rate, only while it shrinks
+	DrainSeconds float64 `json:"drain_seconds,omitempty"`
+	// the samples the trend is derived from
+	WindowSeconds float64 `json:"window_seconds"`
+	Samples       int     `json:"samples"`
+}
+
+// pipelineKey identifies a pipeline across the JobsChecker providers.
+type pipelineKey struct {
+	source   string
+	pipeline string
+}
+
+// compare orders the keys by source, then by pipeline.
+func (k pipelineKey) compare(other pipelineKey) int {
+	return cmp.Or(strings.Compare(k.source, other.source), strings.Compare(k.pipeline, other.pipeline))
+}
+
+// String returns the key as logged, source/pipeline.
+func (k pipelineKey) String() string {
+	return k.source + "/" + k.pipeline
+}
+
+// sampler samples the pipelines of every JobsChecker on a fixed interval and
+// keeps the latest samples of each, from which the trends are derived.
+type sampler struct {
+	log      *slog.Logger
+	interval time.Duration
+	timeout  time.Duration
+	size     int
+	registry map[string]JobsChecker
+	// fed with every sample, may be nil
+	stuck *stuckDetector
+	// the states of the latest sample, exported by /metrics
+	latest *jobsCache
+
+	mu      sync.RWMutex
+	samples map[pipelineKey][]JobsSample
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func newSampler(log *slog.Logger, registry map[string]JobsChecker, interval, timeout time.Duration, size int) *sampler {
+	return &sampler{
+		log:      log,
+		interval: interval,
+		timeout:  timeout,
+		size:     size,
+		registry: registry,
+		samples:  make(map[pipelineKey][]JobsSample),
+		latest:   &jobsCache{maxAge: 3 * interval},
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// run samples until stop is called, the first time immediately.
+func (s *sampler) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sample()
+
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *sampler) stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
+// sample takes a single sample of every pipeline. The pipelines a provider no
+// longer reports are dropped; the ones of a failed provider are kept.
+func (s *sampler) sample() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// abort the round on stop instead of waiting for the check timeout
+	go func() {
+		select {
+		case <-s.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	results := collectJobs(ctx, s.registry, s.timeout)
+	now := time.Now()
+	s.stuck.observe(results, now)
+	s.latest.store(results)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[pipelineKey]struct{})
+	for _, res := range results {
+		if res.err != nil {
+			s.log.Debug("failed to sample the job pipelines", "plugin", res.source, "error", res.err)
+			for key := range s.samples {
+				if key.source == res.source {
+					seen[key] = struct{}{}
+				}
+			}
+			continue
+		}
+
+		for _, js := range res.states {
+			key := pipelineKey{source: res.source, pipeline: js.Pipeline}
+			seen[key] = struct{}{}
+
+			samples := s.samples[key]
+			if len(samples) == s.size {
+				samples = slices.Delete(samples, 0, 1)
+			}
+
+			s.samples[key] = append(samples, JobsSample{
+				Source:    res.source,
+				Pipeline:  js.Pipeline,
+				SampledAt: now,
+				Active:    js.Active,
+				Delayed:   js.Delayed,
+				Reserved:  js.Reserved,
+			})
+		}
+	}
+
+	for key := range s.samples {
+		if _, ok := seen[key]; !ok {
+			delete(s.samples, key)
+		}
+	}
+}
+
+// trend derives the trend of the pipeline from its oldest and newest sample,
+// nil until there are two of them.
+func (s *sampler) trend(key pipelineKey) *JobsTrend {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples := s.samples[key]
+	if len(samples) < 2 {
+		return nil
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	window := last.SampledAt.Sub(first.SampledAt).Seconds()
+	if window <= 0 {
+		return nil
+	}
+
+	backlog := last.Active + last.Delayed + last.Reserved
+
+	tr := &JobsTrend{
+		ActiveRate:    float64(last.Active-first.Active) / window,
+		DelayedRate:   float64(last.Delayed-first.Delayed) / window,
+		BacklogRate:   float64(backlog-(first.Active+first.Delayed+first.Reserved)) / window,
+		WindowSeconds: window,
+		Samples:       len(samples),
+	}
+
+	switch {
+	case tr.BacklogRate > 0:
+		tr.Backlog = trendGrowing
+	case tr.BacklogRate < 0:
+		tr.Backlog = trendShrinking
+		tr.DrainSeconds = float64(backlog) / -tr.BacklogRate
+	default:
+		tr.Backlog = trendSteady
+	}
+
+	return tr
+}
+
+// annotate sets the trend of every sampled pipeline in report. A nil sampler
+// leaves the report alone.
+func (s *sampler) annotate(report []*JobsReport) {
+	if s == nil {
+		return
+	}
+
+	for _, rep := range report {
+		if rep.Pipeline != "" {
+			rep.Trend = s.trend(pipelineKey{source: rep.Source, pipeline: rep.Pipeline})
+		}
+	}
+}
+
+// history returns the samples of the named pipelines, or of all pipelines if
+// names is empty, ordered by time. A nil sampler has none.
+func (s *sampler) history(names []string) []JobsSample {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []JobsSample
+	for key, samples := range s.samples {
+		if len(names) == 0 || slices.Contains(names, key.pipeline) {
+			out = append(out, samples...)
+		}
+	}
+
+	slices.SortStableFunc(out, func(a, b JobsSample) int {
+		return cmp.Or(a.SampledAt.Compare(b.SampledAt), cmp.Compare(a.Source, b.Source), cmp.Compare(a.Pipeline, b.Pipeline))
+	})
+
+	return out
+}
+
+// jobsHistoryHandler serves the samples of the job pipelines, optionally
+// filtered with the ?pipeline= query parameter.
+type jobsHistoryHandler struct {
+	log     *slog.Logger
+	sampler *sampler
+}
+
+func (jh *jobsHistoryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	samples := jh.sampler.history(r.URL.Query()[pipelineQuery])
+	if samples == nil {
+		samples = []JobsSample{}
+	}
+
+	data, err := json.Marshal(samples)
+	if err != nil {
+		jh.log.Error("failed to marshal jobs history", "error", err)
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		jh.log.Error("failed to write jobs history", "error", err)
+	}
+}
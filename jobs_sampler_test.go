@@ -0,0 +1,143 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler(t *testing.T) {
+	jc := &mockJobsChecker{states: []*jobsApi.State{
+		{Pipeline: "emails", Active: 1},
+		{Pipeline: "orders", Active: 2},
+	}}
+	s := newSampler(slog.New(slog.DiscardHandler), jobsRegistry(jc), time.Hour, time.Second, 3)
+	emails := pipelineKey{source: "jobs", pipeline: "emails"}
+
+	for range 5 {
+		s.sample()
+	}
+	assert.Len(t, s.samples[emails], 3)
+	assert.Len(t, s.history(nil), 6)
+	assert.Len(t, s.history([]string{"orders"}), 3)
+
+	// a failed provider keeps its samples
+	jc.err = errors.New("connection refused")
+	s.sample()
+	assert.Len(t, s.samples[emails], 3)
+
+	// a pipeline the provider no longer reports is dropped
+	jc.err = nil
+	jc.states = jc.states[:1]
+	s.sample()
+	assert.Contains(t, s.samples, emails)
+	assert.NotContains(t, s.samples, pipelineKey{source: "jobs", pipeline: "orders"})
+
+	t.Run("Stop", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			s.run()
+			close(done)
+		}()
+
+		s.stop()
+		s.stop()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.Fatal("the sampler did not stop")
+		}
+	})
+}
+
+func TestSamplerTrend(t *testing.T) {
+	s := newSampler(slog.New(slog.DiscardHandler), nil, time.Hour, time.Second, 10)
+	key := pipelineKey{source: "jobs", pipeline: "emails"}
+	start := time.Now()
+
+	// record sets the samples of the pipeline, one per 10 seconds
+	record := func(backlogs ...int64) {
+		s.samples[key] = nil
+		for i, b := range backlogs {
+			s.samples[key] = append(s.samples[key], JobsSample{
+				Source:    key.source,
+				Pipeline:  key.pipeline,
+				SampledAt: start.Add(time.Duration(i) * time.Second * 10),
+				Active:    b,
+				Delayed:   1,
+			})
+		}
+	}
+
+	record(100)
+	assert.Nil(t, s.trend(key))
+
+	record(100, 150, 200)
+	tr := s.trend(key)
+	require.NotNil(t, tr)
+	assert.Equal(t, trendGrowing, tr.Backlog)
+	assert.InDelta(t, 5, tr.ActiveRate, 1e-9)
+	assert.InDelta(t, 0, tr.DelayedRate, 1e-9)
+	assert.InDelta(t, 20, tr.WindowSeconds, 1e-9)
+	assert.Equal(t, 3, tr.Samples)
+	assert.Zero(t, tr.DrainSeconds)
+
+	record(200, 100)
+	tr = s.trend(key)
+	require.NotNil(t, tr)
+	assert.Equal(t, trendShrinking, tr.Backlog)
+	// 101 jobs left at 10 jobs per second
+	assert.InDelta(t, 10.1, tr.DrainSeconds, 1e-9)
+
+	record(10000, 10000)
+	assert.Equal(t, trendSteady, s.trend(key).Backlog)
+}
+
+func TestJobsTrends(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "emails", Active: 1}}}
+	s := newSampler(log, jobsRegistry(jc), time.Hour, time.Second, 10)
+	s.sample()
+	time.Sleep(time.Millisecond * 5)
+	jc.states = []*jobsApi.State{{Pipeline: "emails", Active: 3}}
+	s.sample()
+
+	h := NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable, withSampler(s))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil))
+
+	reports := parseJobsReports(t, rec.Body.Bytes())
+	require.Len(t, reports, 1)
+	require.NotNil(t, reports[0].Trend)
+	assert.Equal(t, trendGrowing, reports[0].Trend.Backlog)
+	assert.Equal(t, 2, reports[0].Trend.Samples)
+
+	t.Run("History", func(t *testing.T) {
+		hh := &jobsHistoryHandler{log: log, sampler: s}
+		rec := httptest.NewRecorder()
+		hh.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs/history?pipeline=emails", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var samples []JobsSample
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &samples))
+		require.Len(t, samples, 2)
+		assert.Equal(t, int64(1), samples[0].Active)
+		assert.Equal(t, int64(3), samples[1].Active)
+
+		// without sampling there is no history
+		hh = &jobsHistoryHandler{log: log}
+		rec = httptest.NewRecorder()
+		hh.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs/history", nil))
+		assert.JSONEq(t, "[]", rec.Body.String())
+	})
+}
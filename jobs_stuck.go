@@ -0,0 +1,168 @@
+package status
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// progress is what the stuck detector knows of a pipeline: its last active and
+// reserved jobs, since when they have not changed, and since when it has not
+// been ready.
+type progress struct {
+	seen          time.Time
+	active        int64
+	reserved      int64
+	unchanged     time.Time
+	notReadySince time.Time
+}
+
+// stuckDetector flags the pipelines whose consumers hang: active or reserved
+// jobs that stay the same for stuckAfter, or a pipeline that stays not ready
+// longer than notReadyGrace; either is disabled if 0. It learns from every
+// pipeline state observed by /jobs, the poller and the sampler, so a pipeline
+// is flagged no sooner than two observations apart.
+type stuckDetector struct {
+	stuckAfter    time.Duration
+	notReadyGrace time.Duration
+
+	mu       sync.Mutex
+	progress map[pipelineKey]*progress
+}
+
+func newStuckDetector(stuckAfter, notReadyGrace time.Duration) *stuckDetector {
+	return &stuckDetector{
+		stuckAfter:    stuckAfter,
+		notReadyGrace: notReadyGrace,
+		progress:      make(map[pipelineKey]*progress),
+	}
+}
+
+// observe records the pipeline states of results, taken at. States older than
+// the last observed ones are ignored. The pipelines a provider no longer reports
+// are forgotten; the ones of a failed provider are kept. A nil detector ignores
+// them.
+func (d *stuckDetector) observe(results []jobsResult, at time.Time) {
+	if d == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[pipelineKey]struct{})
+	for _, res := range results {
+		if res.err != nil {
+			for key := range d.progress {
+				if key.source == res.source {
+					seen[key] = struct{}{}
+				}
+			}
+			continue
+		}
+
+		for _, js := range res.states {
+			key := pipelineKey{source: res.source, pipeline: js.Pipeline}
+			seen[key] = struct{}{}
+
+			p, ok := d.progress[key]
+			if !ok {
+				p = &progress{active: js.Active, reserved: js.Reserved, unchanged: at}
+				d.progress[key] = p
+			}
+			if at.Before(p.seen) {
+				continue
+			}
+			p.seen = at
+
+			if js.Active != p.active || js.Reserved != p.reserved {
+				p.active, p.reserved, p.unchanged = js.Active, js.Reserved, at
+			}
+
+			switch {
+			case js.Ready:
+				p.notReadySince = time.Time{}
+			case p.notReadySince.IsZero():
+				p.notReadySince = at
+			}
+		}
+	}
+
+	for key := range d.progress {
+		if _, ok := seen[key]; !ok {
+			delete(d.progress, key)
+		}
+	}
+}
+
+// check returns why the pipeline is stuck and since when, none if it is not.
+func (d *stuckDetector) check(key pipelineKey) ([]string, time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.progress[key]
+	if !ok {
+		return nil, time.Time{}
+	}
+
+	var (
+		reasons []string
+		since   time.Time
+	)
+
+	if d.stuckAfter > 0 && (p.active > 0 || p.reserved > 0) {
+		if idle := p.seen.Sub(p.unchanged); idle >= d.stuckAfter {
+			reasons = append(reasons, fmt.Sprintf("%d active and %d reserved jobs unchanged for %s", p.active, p.reserved, idle.Round(time.Second)))
+			since = p.unchanged
+		}
+	}
+
+	if d.notReadyGrace > 0 && !p.notReadySince.IsZero() {
+		if down := p.seen.Sub(p.notReadySince); down >= d.notReadyGrace {
+			reasons = append(reasons, fmt.Sprintf("pipeline not ready for %s", down.Round(time.Second)))
+			if since.IsZero() || p.notReadySince.Before(since) {
+				since = p.notReadySince
+			}
+		}
+	}
+
+	return reasons, since
+}
+
+// annotate flags the stuck pipelines in report. A nil detector leaves the report
+// alone.
+func (d *stuckDetector) annotate(report []*JobsReport) {
+	if d == nil {
+		return
+	}
+
+	for _, rep := range report {
+		if rep.Pipeline != "" {
+			rep.Stuck, rep.StuckSince = d.check(pipelineKey{source: rep.Source, pipeline: rep.Pipeline})
+		}
+	}
+}
+
+// stuck returns the reasons of every stuck pipeline, keyed by pipeline name. A
+// nil detector flags none.
+func (d *stuckDetector) stuck() map[string][]string {
+	out := make(map[string][]string)
+	if d == nil {
+		return out
+	}
+
+	d.mu.Lock()
+	keys := make([]pipelineKey, 0, len(d.progress))
+	for key := range d.progress {
+		keys = append(keys, key)
+	}
+	d.mu.Unlock()
+
+	for _, key := range keys {
+		if reasons, _ := d.check(key); len(reasons) > 0 {
+			out[key.pipeline] = append(out[key.pipeline], reasons...)
+		}
+	}
+
+	return out
+}
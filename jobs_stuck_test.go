@@ -0,0 +1,111 @@
+package status
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStuckDetector(t *testing.T) {
+	d := newStuckDetector(time.Minute, time.Minute*5)
+	emails := pipelineKey{source: "jobs", pipeline: "emails"}
+	start := time.Now()
+
+	observe := func(after time.Duration, states ...*jobsApi.State) {
+		d.observe([]jobsResult{{source: "jobs", states: states}}, start.Add(after))
+	}
+
+	observe(0, &jobsApi.State{Pipeline: "emails", Ready: true, Reserved: 3})
+	observe(time.Second*30, &jobsApi.State{Pipeline: "emails", Ready: true, Reserved: 3})
+	reasons, _ := d.check(emails)
+	assert.Empty(t, reasons)
+
+	observe(time.Second*60, &jobsApi.State{Pipeline: "emails", Ready: true, Reserved: 3})
+	reasons, since := d.check(emails)
+	assert.Equal(t, []string{"0 active and 3 reserved jobs unchanged for 1m0s"}, reasons)
+	assert.Equal(t, start, since)
+
+	// an older state changes nothing
+	observe(time.Second*10, &jobsApi.State{Pipeline: "emails", Ready: true, Reserved: 4})
+	reasons, _ = d.check(emails)
+	assert.Len(t, reasons, 1)
+
+	// progress resets the clock
+	observe(time.Second*90, &jobsApi.State{Pipeline: "emails", Ready: true, Reserved: 2})
+	reasons, _ = d.check(emails)
+	assert.Empty(t, reasons)
+
+	// an idle pipeline is not stuck
+	observe(time.Minute*5, &jobsApi.State{Pipeline: "emails", Ready: false})
+	observe(time.Minute*8, &jobsApi.State{Pipeline: "emails", Ready: false})
+	reasons, _ = d.check(emails)
+	assert.Empty(t, reasons)
+
+	observe(time.Minute*10, &jobsApi.State{Pipeline: "emails", Ready: false})
+	reasons, since = d.check(emails)
+	assert.Equal(t, []string{"pipeline not ready for 5m0s"}, reasons)
+	assert.Equal(t, start.Add(time.Minute*5), since)
+	assert.Equal(t, map[string][]string{"emails": reasons}, d.stuck())
+
+	report := []*JobsReport{{Source: "jobs", Pipeline: "emails"}, {Source: "jobs", Pipeline: "orders"}}
+	d.annotate(report)
+	assert.Equal(t, reasons, report[0].Stuck)
+	assert.Equal(t, since, report[0].StuckSince)
+	assert.Empty(t, report[1].Stuck)
+
+	// a pipeline the provider no longer reports is forgotten
+	observe(time.Minute * 11)
+	assert.Empty(t, d.stuck())
+
+	var none *stuckDetector
+	none.observe([]jobsResult{{source: "jobs", states: []*jobsApi.State{{Pipeline: "emails"}}}}, start)
+	none.annotate(report)
+	assert.Empty(t, none.stuck())
+}
+
+func TestJobsHandlerStuck(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "emails", Ready: true, Active: 1}}}
+	d := newStuckDetector(time.Millisecond, 0)
+	h := NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable, withStuckDetector(d))
+
+	serve := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil))
+		return rec
+	}
+
+	reports := parseJobsReports(t, serve().Body.Bytes())
+	require.Len(t, reports, 1)
+	assert.Empty(t, reports[0].Stuck)
+
+	time.Sleep(time.Millisecond * 5)
+
+	// a stuck pipeline is reported, /jobs keeps its status code
+	rec := serve()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	reports = parseJobsReports(t, rec.Body.Bytes())
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0].Stuck, 1)
+	assert.Contains(t, reports[0].Stuck[0], "1 active and 0 reserved jobs unchanged")
+	assert.False(t, reports[0].StuckSince.IsZero())
+
+	t.Run("Ready", func(t *testing.T) {
+		jr := &jobsReadiness{log: log, registry: jobsRegistry(jc), stuck: d, timeout: time.Second}
+		st, err := jr.Ready()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, st.Code)
+
+		jc.states = []*jobsApi.State{{Pipeline: "emails", Ready: true, Active: 2}}
+		st, err = jr.Ready()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, st.Code)
+	})
+}
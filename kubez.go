@@ -0,0 +1,184 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	kubezPluginPath = "plugin"
+	verboseQuery    = "verbose"
+	excludeQuery    = "exclude"
+)
+
+// kubez serves /livez or /readyz with the semantics of the kube-apiserver: "ok"
+// when the probe passes, otherwise, or with ?verbose, a line per check and the
+// overall result. ?exclude= skips the named plugins and /livez/{plugin} or
+// /readyz/{plugin} checks a single one. The checks are the ones of /health or
+// /ready without ?plugin=, sharing their thresholds and history, of which the
+// selected plugins are reported.
+type kubez struct {
+	log *slog.Logger
+	// "livez" or "readyz", as named in the result line
+	name                  string
+	unavailableStatusCode int
+	shutdownInitiated     *atomic.Bool
+	opts                  *handlerOptions
+	// names of the registered plugins, and whether one is not nil
+	plugins func() []string
+	found   func(name string) bool
+	check   func(ctx context.Context, timeout time.Duration, plg []string) []*Report
+}
+
+// livez serves the checks of h at /livez. Like /health, it passes during the
+// graceful shutdown.
+func livez(h *Health) *kubez {
+	return &kubez{
+		log:                   h.log,
+		name:                  "livez",
+		unavailableStatusCode: h.unavailableStatusCode,
+		shutdownInitiated:     h.shutdownInitiated,
+		opts:                  &h.opts,
+		plugins:               func() []string { return slices.Sorted(maps.Keys(h.statusRegistry)) },
+		found:                 func(name string) bool { return h.statusRegistry[name] != nil },
+		check:                 h.check,
+	}
+}
+
+// readyz serves the checks of rd at /readyz. Like /ready, it fails during the
+// graceful shutdown and the maintenance mode.
+func readyz(rd *Ready) *kubez {
+	return &kubez{
+		log:                   rd.log,
+		name:                  "readyz",
+		unavailableStatusCode: rd.unavailableStatusCode,
+		shutdownInitiated:     rd.shutdownInitiated,
+		opts:                  &rd.opts,
+		plugins:               func() []string { return slices.Sorted(maps.Keys(rd.statusRegistry)) },
+		found:                 func(name string) bool { return rd.statusRegistry[name] != nil },
+		check:                 rd.check,
+	}
+}
+
+func (kz *kubez) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	_, verbose := query[verboseQuery]
+
+	var lines []string
+
+	if kz.shutdownInitiated != nil && kz.shutdownInitiated.Load() {
+		// liveness stays up while draining, as on /health
+		if kz.name == "livez" {
+			kz.write(w, verbose, false, []string{"[+]shutdown ok"})
+			return
+		}
+
+		kz.write(w, verbose, true, []string{"[-]shutdown failed: " + shutdownMessage})
+		return
+	}
+
+	if st := kz.opts.maintenance.state(); st.On {
+		kz.write(w, verbose, true, []string{"[-]maintenance failed: " + st.message()})
+		return
+	}
+
+	timeout, err := requestTimeout(r, kz.opts.checkTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	plugins := kz.plugins()
+	if name := r.PathValue(kubezPluginPath); name != "" {
+		if !slices.Contains(plugins, name) {
+			http.Error(w, fmt.Sprintf("no such check: %q", name), http.StatusNotFound)
+			return
+		}
+
+		plugins = []string{name}
+	} else if excluded := query[excludeQuery]; len(excluded) > 0 {
+		var unmatched []string
+		for _, name := range excluded {
+			if !slices.Contains(plugins, name) {
+				unmatched = append(unmatched, fmt.Sprintf("%q", name))
+			}
+		}
+
+		plugins = slices.DeleteFunc(plugins, func(name string) bool { return slices.Contains(excluded, name) })
+		if len(unmatched) > 0 {
+			lines = append(lines, "warn: some checks cannot be excluded: no matches for "+strings.Join(unmatched, ","))
+		}
+	}
+
+	// every plugin is checked as on /health and /ready, which treat the errors
+	// of the plugins named by ?plugin= differently
+	var report []*Report
+	if len(plugins) > 0 {
+		report = slices.DeleteFunc(kz.check(r.Context(), timeout, nil), func(rep *Report) bool {
+			return !slices.Contains(plugins, rep.PluginName)
+		})
+	}
+
+	// the nil entries of the registry are not checked, but still listed
+	for i, rep := range report {
+		if !kz.found(rep.PluginName) {
+			report[i] = notFoundReport(rep.PluginName, "plugin not found")
+		}
+	}
+
+	overall := kz.opts.evaluate(report, kz.unavailableStatusCode)
+	slices.SortFunc(report, func(a, b *Report) int { return strings.Compare(a.PluginName, b.PluginName) })
+
+	for _, rep := range report {
+		switch rep.Severity {
+		case SeverityPass:
+			lines = append(lines, "[+]"+rep.PluginName+" ok")
+		case SeverityWarn:
+			lines = append(lines, "[+]"+rep.PluginName+" warn: "+reportReason(rep))
+		default:
+			lines = append(lines, "[-]"+rep.PluginName+" failed: "+reportReason(rep))
+		}
+	}
+
+	w.Header().Set(HealthStatusHeader, overall)
+	kz.write(w, verbose, overall == SeverityFail, lines)
+}
+
+// write writes "ok" if the probe passed, otherwise, or if verbose, the lines of
+// the checks followed by the overall result.
+func (kz *kubez) write(w http.ResponseWriter, verbose, failed bool, lines []string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	var body string
+	switch {
+	case failed:
+		w.WriteHeader(kz.unavailableStatusCode)
+		body = strings.Join(append(lines, kz.name+" check failed"), "\n") + "\n"
+	case verbose:
+		body = strings.Join(append(lines, kz.name+" check passed"), "\n") + "\n"
+	default:
+		body = "ok"
+	}
+
+	_, err := w.Write([]byte(body))
+	if err != nil {
+		kz.log.Error("failed to write "+kz.name, "error", err)
+	}
+}
+
+// reportReason returns the error of rep, or its status code if it carries none.
+func reportReason(rep *Report) string {
+	if rep.ErrorMessage != "" {
+		return rep.ErrorMessage
+	}
+
+	return fmt.Sprintf("status code %d", rep.StatusCode)
+}
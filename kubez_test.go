@@ -0,0 +1,189 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubez(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	shutdown := newShutdownPtr(false)
+	m := newMaintenance(log)
+
+	readyRegistry := map[string]Readiness{
+		"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}},
+		"grpc": &mockReadiness{name: "grpc", err: errors.New("no workers")},
+		"kv":   &mockReadiness{name: "kv", st: &apiStatus.Status{Code: http.StatusInternalServerError}},
+	}
+	statusRegistry := map[string]Checker{
+		"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: http.StatusOK}},
+		"logs": &mockChecker{name: "logs", st: &apiStatus.Status{Code: http.StatusInternalServerError}},
+	}
+
+	health := NewHealthHandler(statusRegistry, shutdown, log, http.StatusServiceUnavailable, WithNonCriticalPlugins("logs"))
+	ready := NewReadyHandler(readyRegistry, shutdown, log, http.StatusServiceUnavailable, withMaintenance(m), withHistory(newHistory(10)))
+
+	mux := http.NewServeMux()
+	mux.Handle("/livez", livez(health))
+	mux.Handle("/livez/{"+kubezPluginPath+"}", livez(health))
+	mux.Handle("/readyz", readyz(ready))
+	mux.Handle("/readyz/{"+kubezPluginPath+"}", readyz(ready))
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+		body string
+	}{
+		{
+			name: "Failed",
+			url:  "/readyz",
+			code: http.StatusServiceUnavailable,
+			// an error of a Readiness plugin does not fail /ready either
+			body: "[+]grpc warn: no workers\n[+]http ok\n[-]kv failed: internal server error, see logs\nreadyz check failed\n",
+		},
+		{
+			name: "Excluded",
+			url:  "/readyz?exclude=grpc&exclude=kv",
+			code: http.StatusOK,
+			body: "ok",
+		},
+		{
+			name: "Excluded_Verbose",
+			url:  "/readyz?exclude=grpc&exclude=kv&exclude=jobs&verbose",
+			code: http.StatusOK,
+			body: "warn: some checks cannot be excluded: no matches for \"jobs\"\n[+]http ok\nreadyz check passed\n",
+		},
+		{
+			name: "Single",
+			url:  "/readyz/http",
+			code: http.StatusOK,
+			body: "ok",
+		},
+		{
+			name: "Single_Failed",
+			url:  "/readyz/kv",
+			code: http.StatusServiceUnavailable,
+			body: "[-]kv failed: internal server error, see logs\nreadyz check failed\n",
+		},
+		{
+			name: "Single_Error",
+			url:  "/readyz/grpc?verbose",
+			code: http.StatusOK,
+			body: "[+]grpc warn: no workers\nreadyz check passed\n",
+		},
+		{
+			name: "Single_Unknown",
+			url:  "/readyz/jobs",
+			code: http.StatusNotFound,
+			body: "no such check: \"jobs\"\n",
+		},
+		{
+			name: "ExcludedAll",
+			url:  "/livez?exclude=http&exclude=logs&verbose",
+			code: http.StatusOK,
+			body: "livez check passed\n",
+		},
+		{
+			name: "NonCritical_Verbose",
+			url:  "/livez?verbose",
+			code: http.StatusOK,
+			body: "[+]http ok\n[+]logs warn: internal server error, see logs\nlivez check passed\n",
+		},
+		{
+			name: "BadTimeout",
+			url:  "/livez?timeout=-1s",
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rec.Body.String())
+				assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+
+	t.Run("CheckerError", func(t *testing.T) {
+		// an error of a Checker plugin fails /health, and so /livez
+		registry := map[string]Checker{"http": &mockChecker{name: "http", err: errors.New("boom")}}
+		h := livez(NewHealthHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, withHistory(newHistory(10))))
+
+		for _, url := range []string{"/livez", "/livez/http"} {
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+			req.SetPathValue(kubezPluginPath, strings.TrimPrefix(strings.TrimPrefix(url, "/livez"), "/"))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusServiceUnavailable, rec.Code, url)
+			assert.Equal(t, "[-]http failed: boom\nlivez check failed\n", rec.Body.String(), url)
+		}
+	})
+
+	t.Run("NilPlugin", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}},
+			"kv":   nil,
+		}
+		h := readyz(NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, withHistory(newHistory(10))))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz?verbose", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "[+]http ok\n[-]kv failed: plugin not found\nreadyz check failed\n", rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz?exclude=kv", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	// the checks are recorded like the ones of /ready
+	require.NotEmpty(t, ready.opts.history.entries([]string{"http"}))
+
+	t.Run("Maintenance", func(t *testing.T) {
+		m.set(true, "upgrade")
+		t.Cleanup(func() { m.set(false, "") })
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz/http", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "[-]maintenance failed: ")
+		assert.Contains(t, rec.Body.String(), "upgrade")
+
+		// liveness is not affected by the maintenance
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/livez/http", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Shutdown", func(t *testing.T) {
+		shutdown.Store(true)
+		t.Cleanup(func() { shutdown.Store(false) })
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/readyz?exclude=grpc&exclude=kv", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "[-]shutdown failed: "+shutdownMessage+"\nreadyz check failed\n", rec.Body.String())
+
+		// liveness stays up while draining
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/livez?verbose", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "[+]shutdown ok\nlivez check passed\n", rec.Body.String())
+	})
+}
@@ -0,0 +1,152 @@
+package status
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaintenanceState is the maintenance mode as returned by the SetMaintenance rpc
+// method and POST /maintenance.
+type MaintenanceState struct {
+	On     bool      `json:"on"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since,omitzero"`
+}
+
+// message is the response body of a probe failed by the maintenance mode.
+func (st MaintenanceState) message() string {
+	if st.Reason == "" {
+		return "service is in maintenance"
+	}
+
+	return "service is in maintenance: " + st.Reason
+}
+
+// writeMaintenance writes st with the unavailable status code. A request
+// accepting HealthJSONContentType gets a failing health+json document instead,
+// with the maintenance as its single check.
+func (o *handlerOptions) writeMaintenance(w http.ResponseWriter, r *http.Request, log *slog.Logger, usc int, st MaintenanceState) {
+	var body any = st
+	if acceptsHealthJSON(r) {
+		body = &HealthJSON{
+			Status:    SeverityFail,
+			ServiceID: o.serviceID,
+			Output:    st.message(),
+			Time:      time.Now(),
+			Checks: map[string][]HealthJSONCheck{"maintenance": {{
+				ComponentType: "system",
+				ObservedValue: usc,
+				ObservedUnit:  "status_code",
+				Status:        SeverityFail,
+				Time:          st.Since,
+				Output:        st.message(),
+			}}},
+		}
+		w.Header().Set("Content-Type", HealthJSONContentType)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Error("failed to marshal maintenance state", "error", err)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set(HealthStatusHeader, SeverityFail)
+	w.WriteHeader(usc)
+
+	_, err = w.Write(data)
+	if err != nil {
+		log.Error("failed to write maintenance state", "error", err)
+	}
+}
+
+// maintenance is a manual switch failing /ready (and optionally /jobs) with the
+// unavailable status code, which, unlike the shutdown, can be turned off again.
+type maintenance struct {
+	log *slog.Logger
+
+	mu sync.RWMutex
+	st MaintenanceState
+}
+
+func newMaintenance(log *slog.Logger) *maintenance {
+	return &maintenance{log: log}
+}
+
+// set turns the maintenance mode on or off and returns the new state. Turning
+// it on again only updates the reason.
+func (m *maintenance) set(on bool, reason string) MaintenanceState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case on && !m.st.On:
+		m.st = MaintenanceState{On: true, Reason: reason, Since: time.Now()}
+		m.log.Info("maintenance mode enabled", "reason", reason)
+	case on:
+		m.st.Reason = reason
+	case m.st.On:
+		m.st = MaintenanceState{}
+		m.log.Info("maintenance mode disabled")
+	}
+
+	return m.st
+}
+
+// state returns the current state, off for a nil maintenance.
+func (m *maintenance) state() MaintenanceState {
+	if m == nil {
+		return MaintenanceState{}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.st
+}
+
+// maintenanceHandler toggles the maintenance mode with a POST of a
+// MaintenanceRequest, authenticated with the configured bearer token.
+type maintenanceHandler struct {
+	log         *slog.Logger
+	maintenance *maintenance
+	token       string
+}
+
+func (mh *maintenanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(mh.token)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var in MaintenanceRequest
+	err := json.NewDecoder(r.Body).Decode(&in)
+	if err != nil {
+		http.Error(w, "invalid maintenance request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(mh.maintenance.set(in.On, in.Reason))
+	if err != nil {
+		mh.log.Error("failed to marshal maintenance state", "error", err)
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		mh.log.Error("failed to write maintenance state", "error", err)
+	}
+}
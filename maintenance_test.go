@@ -0,0 +1,132 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	m := newMaintenance(log)
+
+	rr := map[string]Readiness{
+		"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+	}
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "pipe1", Ready: true}}}
+
+	ready := NewReadyHandler(rr, newShutdownPtr(false), log, http.StatusServiceUnavailable, withMaintenance(m))
+	jobs := NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+
+	serve := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, target, nil))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve(ready, "/ready").Code)
+
+	st := m.set(true, "database migration")
+	assert.True(t, st.On)
+	assert.False(t, st.Since.IsZero())
+
+	rec := serve(ready, "/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, SeverityFail, rec.Header().Get(HealthStatusHeader))
+
+	var body MaintenanceState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.On)
+	assert.Equal(t, "database migration", body.Reason)
+	assert.True(t, st.Since.Equal(body.Since))
+
+	// a health+json client gets the maintenance as a failing check
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil)
+	req.Header.Set("Accept", HealthJSONContentType)
+	rec = httptest.NewRecorder()
+	ready.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, HealthJSONContentType, rec.Header().Get("Content-Type"))
+
+	var doc HealthJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, SeverityFail, doc.Status)
+	assert.Equal(t, "service is in maintenance: database migration", doc.Output)
+	require.Len(t, doc.Checks["maintenance"], 1)
+	assert.Equal(t, SeverityFail, doc.Checks["maintenance"][0].Status)
+
+	// /jobs only fails when given the maintenance option
+	assert.Equal(t, http.StatusOK, serve(jobs, "/jobs").Code)
+	jobs = NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable, withMaintenance(m))
+	rec = serve(jobs, "/jobs")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.On)
+
+	// turning it on again keeps the start time
+	again := m.set(true, "still migrating")
+	assert.Equal(t, st.Since, again.Since)
+	assert.Equal(t, "still migrating", again.Reason)
+
+	assert.Equal(t, MaintenanceState{}, m.set(false, ""))
+	assert.Equal(t, http.StatusOK, serve(ready, "/ready").Code)
+	assert.Equal(t, http.StatusOK, serve(jobs, "/jobs").Code)
+}
+
+func TestMaintenanceHandler(t *testing.T) {
+	m := newMaintenance(slog.New(slog.DiscardHandler))
+	mh := &maintenanceHandler{log: slog.New(slog.DiscardHandler), maintenance: m, token: "secret"}
+
+	post := func(method, auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), method, "/maintenance", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+
+		rec := httptest.NewRecorder()
+		mh.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(http.MethodGet, "Bearer secret", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+
+	assert.Equal(t, http.StatusUnauthorized, post(http.MethodPost, "", `{"on": true}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, post(http.MethodPost, "Bearer wrong", `{"on": true}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(http.MethodPost, "Bearer secret", `{"on":`).Code)
+	assert.False(t, m.state().On)
+
+	rec = post(http.MethodPost, "Bearer secret", `{"on": true, "reason": "upgrade"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var st MaintenanceState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st))
+	assert.True(t, st.On)
+	assert.Equal(t, "upgrade", st.Reason)
+	assert.True(t, m.state().On)
+}
+
+func TestMaintenanceRPC(t *testing.T) {
+	p := &Plugin{log: slog.New(slog.DiscardHandler)}
+	p.maintenance = newMaintenance(p.log)
+	r := &rpc{srv: p, log: p.log}
+
+	var out MaintenanceState
+	require.NoError(t, r.SetMaintenance(&MaintenanceRequest{On: true, Reason: "upgrade"}, &out))
+	assert.True(t, out.On)
+	assert.Equal(t, "upgrade", p.maintenance.state().Reason)
+
+	require.NoError(t, r.SetMaintenance(&MaintenanceRequest{}, &out))
+	assert.False(t, out.On)
+	assert.False(t, p.maintenance.state().On)
+}
@@ -0,0 +1,171 @@
+package status
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	metricsNamespace = "rr"
+	metricsSubsystem = "status"
+)
+
+// metrics holds the Prometheus collectors served by /metrics, on a registry of
+// their own so they do not clash with the metrics plugin.
+type metrics struct {
+	registry *prometheus.Registry
+
+	statusCode *prometheus.GaugeVec
+	up         *prometheus.GaugeVec
+	duration   *prometheus.HistogramVec
+	jobs       *jobsCollector
+}
+
+// newMetrics registers the collectors. shutdownInitiated backs the shutdown
+// gauge; the pipelines are exported once exportJobs is called.
+func newMetrics(shutdownInitiated *atomic.Bool) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		statusCode: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "plugin_status_code",
+			Help:      "Status code of the last check of the plugin.",
+		}, []string{"probe", "plugin"}),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "plugin_up",
+			Help:      "Whether the last check of the plugin passed (1) or not (0).",
+		}, []string{"probe", "plugin"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "check_duration_seconds",
+			Help:      "Duration of the Status and Ready calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"probe", "plugin"}),
+		jobs: newJobsCollector(),
+	}
+
+	m.registry.MustRegister(
+		m.statusCode,
+		m.up,
+		m.duration,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "shutting_down",
+			Help:      "Whether the graceful shutdown is in progress (1) or not (0).",
+		}, func() float64 {
+			if shutdownInitiated != nil && shutdownInitiated.Load() {
+				return 1
+			}
+
+			return 0
+		}),
+		m.jobs,
+	)
+
+	return m
+}
+
+// observe records the durations of the checks run for probe. A nil metrics
+// records nothing.
+func (m *metrics) observe(probe string, targets []checkTarget, results []checkResult) {
+	if m == nil {
+		return
+	}
+
+	for i, res := range results {
+		m.duration.WithLabelValues(probe, targets[i].name).Observe(res.duration.Seconds())
+	}
+}
+
+// record sets the status code and up gauges of the plugins from the evaluated
+// reports of probe. A nil metrics records nothing.
+func (m *metrics) record(probe string, report []*Report) {
+	if m == nil {
+		return
+	}
+
+	for _, rep := range report {
+		m.statusCode.WithLabelValues(probe, rep.PluginName).Set(float64(rep.StatusCode))
+
+		up := 0.0
+		if rep.Severity == SeverityPass {
+			up = 1
+		}
+		m.up.WithLabelValues(probe, rep.PluginName).Set(up)
+	}
+}
+
+// exportJobs makes every scrape export the pipelines returned by jobs. It must
+// be called before the first scrape.
+func (m *metrics) exportJobs(jobs func() []*JobsReport) {
+	m.jobs.jobs = jobs
+}
+
+// handler serves the collectors in the Prometheus exposition format.
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// jobsCollector exports the state of the job pipelines at scrape time.
+type jobsCollector struct {
+	jobs func() []*JobsReport
+
+	active   *prometheus.Desc
+	delayed  *prometheus.Desc
+	reserved *prometheus.Desc
+	ready    *prometheus.Desc
+}
+
+func newJobsCollector() *jobsCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, metricsSubsystem, name), help, []string{"source", "pipeline", "driver"}, nil)
+	}
+
+	return &jobsCollector{
+		active:   desc("jobs_active", "Number of active jobs of the pipeline."),
+		delayed:  desc("jobs_delayed", "Number of delayed jobs of the pipeline."),
+		reserved: desc("jobs_reserved", "Number of reserved jobs of the pipeline."),
+		ready:    desc("jobs_ready", "Whether the pipeline is ready (1) or not (0)."),
+	}
+}
+
+func (jc *jobsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- jc.active
+	ch <- jc.delayed
+	ch <- jc.reserved
+	ch <- jc.ready
+}
+
+// Collect exports the pipelines reported by a JobsChecker. The report of a
+// failed provider has no pipeline, and the one of a required pipeline no
+// provider reported has no source; neither is exported.
+func (jc *jobsCollector) Collect(ch chan<- prometheus.Metric) {
+	if jc.jobs == nil {
+		return
+	}
+
+	for _, rep := range jc.jobs() {
+		if rep.Source == "" || rep.Pipeline == "" {
+			continue
+		}
+
+		ready := 0.0
+		if rep.Ready {
+			ready = 1
+		}
+
+		labels := []string{rep.Source, rep.Pipeline, rep.Driver}
+		ch <- prometheus.MustNewConstMetric(jc.active, prometheus.GaugeValue, float64(rep.Active), labels...)
+		ch <- prometheus.MustNewConstMetric(jc.delayed, prometheus.GaugeValue, float64(rep.Delayed), labels...)
+		ch <- prometheus.MustNewConstMetric(jc.reserved, prometheus.GaugeValue, float64(rep.Reserved), labels...)
+		ch <- prometheus.MustNewConstMetric(jc.ready, prometheus.GaugeValue, ready, labels...)
+	}
+}
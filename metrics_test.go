@@ -0,0 +1,90 @@
+package status
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+
+	var shutdown atomic.Bool
+	m := newMetrics(&shutdown)
+	m.exportJobs(func() []*JobsReport {
+		return jobsReports([]jobsResult{
+			{source: "jobs", states: []*jobsApi.State{{Pipeline: "emails", Driver: "amqp", Ready: true, Active: 3, Delayed: 2, Reserved: 1}}},
+			{source: "kafka", err: context.DeadlineExceeded},
+		}, time.Now(), &jobsRules{pipelines: map[string]JobsRule{"reports": {Required: true}}})
+	})
+
+	registry := map[string]Readiness{
+		"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}},
+		"grpc": &mockReadiness{name: "grpc", st: &apiStatus.Status{Code: http.StatusInternalServerError}},
+	}
+	h := NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, withMetrics(m, probeReady))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil))
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		m.handler().ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/metrics", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec.Body.String()
+	}
+
+	body := scrape()
+	for _, line := range []string{
+		`rr_status_plugin_status_code{plugin="http",probe="ready"} 200`,
+		`rr_status_plugin_status_code{plugin="grpc",probe="ready"} 503`,
+		`rr_status_plugin_up{plugin="http",probe="ready"} 1`,
+		`rr_status_plugin_up{plugin="grpc",probe="ready"} 0`,
+		`rr_status_check_duration_seconds_count{plugin="http",probe="ready"} 1`,
+		`rr_status_jobs_active{driver="amqp",pipeline="emails",source="jobs"} 3`,
+		`rr_status_jobs_delayed{driver="amqp",pipeline="emails",source="jobs"} 2`,
+		`rr_status_jobs_reserved{driver="amqp",pipeline="emails",source="jobs"} 1`,
+		`rr_status_jobs_ready{driver="amqp",pipeline="emails",source="jobs"} 1`,
+		`rr_status_shutting_down 0`,
+	} {
+		assert.Contains(t, body, line)
+	}
+	// the failed provider and the missing required pipeline have no state
+	assert.NotContains(t, body, `source="kafka"`)
+	assert.NotContains(t, body, `pipeline="reports"`)
+
+	shutdown.Store(true)
+	assert.Contains(t, scrape(), `rr_status_shutting_down 1`)
+
+	// without metrics the handlers record nothing
+	var none *metrics
+	none.observe(probeReady, nil, nil)
+	none.record(probeReady, []*Report{{PluginName: "http"}})
+}
+
+// TestPluginJobsMetrics checks that a scrape exports the cached pipelines when
+// the poller or the sampler runs, and takes fresh ones otherwise.
+func TestPluginJobsMetrics(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true}, initLogger{}))
+	p.statusJobsRegistry["jobs"] = &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "live"}}}
+
+	reports := p.jobsMetrics(nil)()
+	require.Len(t, reports, 1)
+	assert.Equal(t, "live", reports[0].Pipeline)
+
+	cache := &jobsCache{}
+	assert.Empty(t, p.jobsMetrics(cache)())
+
+	cache.store([]jobsResult{{source: "jobs", states: []*jobsApi.State{{Pipeline: "cached"}}}})
+	reports = p.jobsMetrics(cache)()
+	require.Len(t, reports, 1)
+	assert.Equal(t, "cached", reports[0].Pipeline)
+}
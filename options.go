@@ -0,0 +1,195 @@
+package status
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// handlerOptions holds the settings shared by the /health, /ready, /startup and
+// /jobs handlers.
+type handlerOptions struct {
+	// per-check deadline, 0 means no deadline
+	checkTimeout time.Duration
+	// max number of checks running at once, 0 means one per plugin
+	checkConcurrency int
+	// plugins whose failures only warn instead of failing the probe
+	nonCritical map[string]struct{}
+	// whether an unknown or nil plugin in ?plugin= fails the probe
+	strictPlugins bool
+	// serviceId of the health+json responses
+	serviceID string
+	// exports the checks, labeled with the probe type of the handler
+	metrics *metrics
+	probe   string
+	// reported state of the plugins with failure and success thresholds
+	hysteresis *hysteresis
+	// records the reports of every request
+	history *history
+	// fails the probe while on
+	maintenance *maintenance
+	// reports the drain progress during the shutdown
+	drain *drain
+	// shares concurrent checks of the same plugin with other requests
+	coalescer *coalescer
+	// /jobs: the readiness rules of the pipelines
+	jobsRules *jobsRules
+	// /jobs: the samples the trends of the pipelines are derived from
+	sampler *sampler
+	// /jobs: flags the pipelines whose consumers hang
+	stuck *stuckDetector
+	// /startup: the plugins to wait for (all if empty) and how long failures are expected
+	startupPlugins     []string
+	startupGracePeriod time.Duration
+	// polling mode: serve the results of the poller instead of checking
+	cache     *resultCache
+	jobsCache *jobsCache
+}
+
+// HandlerOption customizes a handler built by NewHealthHandler, NewReadyHandler,
+// NewStartupHandler, NewJobsHandler or NewMultiJobsHandler.
+type HandlerOption func(*handlerOptions)
+
+// WithCheckTimeout bounds every Status or Ready call made by the handler. A
+// request may shorten it with the ?timeout= query parameter.
+func WithCheckTimeout(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) { o.checkTimeout = d }
+}
+
+// WithCheckConcurrency limits the number of Status or Ready calls the handler
+// runs at the same time for a single request.
+func WithCheckConcurrency(n int) HandlerOption {
+	return func(o *handlerOptions) { o.checkConcurrency = n }
+}
+
+// WithNonCriticalPlugins marks plugins whose failures only degrade the overall
+// status to warn, instead of failing the probe with the unavailable status code.
+func WithNonCriticalPlugins(names ...string) HandlerOption {
+	return func(o *handlerOptions) {
+		o.nonCritical = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			o.nonCritical[name] = struct{}{}
+		}
+	}
+}
+
+// WithStrictPlugins makes an unknown or nil plugin named in ?plugin= fail the
+// probe with a not-found report, instead of being skipped.
+func WithStrictPlugins(strict bool) HandlerOption {
+	return func(o *handlerOptions) { o.strictPlugins = strict }
+}
+
+// WithServiceID sets the serviceId of the health+json responses.
+func WithServiceID(id string) HandlerOption {
+	return func(o *handlerOptions) { o.serviceID = id }
+}
+
+// WithThresholds sets the failure and success thresholds of plugins, keyed by
+// plugin name. The handler keeps the state of each plugin across requests and
+// changes the reported state only once a threshold is reached. In polling mode
+// every poll round counts once, otherwise every request does.
+func WithThresholds(thresholds map[string]Thresholds) HandlerOption {
+	return func(o *handlerOptions) { o.hysteresis = newHysteresis(thresholds) }
+}
+
+// WithJobsRules sets the readiness rules of the job pipelines, keyed by pipeline
+// and by driver name. The /jobs handler fails with the unavailable status code
+// while a pipeline breaks its rule.
+func WithJobsRules(pipelines, drivers map[string]JobsRule) HandlerOption {
+	return func(o *handlerOptions) { o.jobsRules = &jobsRules{pipelines: pipelines, drivers: drivers} }
+}
+
+// withSampler makes a /jobs handler report the trends of the pipelines sampled
+// by s.
+func withSampler(s *sampler) HandlerOption {
+	return func(o *handlerOptions) { o.sampler = s }
+}
+
+// WithStartupPlugins sets the Readiness plugins the /startup handler waits for,
+// every registered one by default.
+func WithStartupPlugins(names ...string) HandlerOption {
+	return func(o *handlerOptions) {
+		o.startupPlugins = slices.Compact(slices.Sorted(slices.Values(names)))
+	}
+}
+
+// WithStartupGracePeriod sets how long after the start the /startup handler
+// expects plugins not to be ready. Within it, such a plugin is reported as a
+// warning and logged at debug level, and the response carries the rest of the
+// period in Retry-After; after it, the plugin fails and is logged as a warning.
+func WithStartupGracePeriod(d time.Duration) HandlerOption {
+	return func(o *handlerOptions) { o.startupGracePeriod = d }
+}
+
+// withCoalescer makes a /health or /ready handler share the Status or Ready
+// calls of a plugin with every other caller of c.
+func withCoalescer(c *coalescer) HandlerOption {
+	return func(o *handlerOptions) { o.coalescer = c }
+}
+
+// withHistory makes a /health or /ready handler record its reports in h.
+func withHistory(h *history) HandlerOption {
+	return func(o *handlerOptions) { o.history = h }
+}
+
+// withStuckDetector makes a /jobs handler feed the pipeline states it serves to
+// d and flag the stuck pipelines.
+func withStuckDetector(d *stuckDetector) HandlerOption {
+	return func(o *handlerOptions) { o.stuck = d }
+}
+
+// withMaintenance makes a /ready or /jobs handler fail while m is on.
+func withMaintenance(m *maintenance) HandlerOption {
+	return func(o *handlerOptions) { o.maintenance = m }
+}
+
+// withDrain makes a handler report the drain progress of d during the shutdown.
+func withDrain(d *drain) HandlerOption {
+	return func(o *handlerOptions) { o.drain = d }
+}
+
+// withMetrics makes a handler export its checks to m, labeled with probe.
+func withMetrics(m *metrics, probe string) HandlerOption {
+	return func(o *handlerOptions) { o.metrics, o.probe = m, probe }
+}
+
+// withResultCache makes a /health or /ready handler serve the results the
+// poller wrote to c.
+func withResultCache(c *resultCache) HandlerOption {
+	return func(o *handlerOptions) { o.cache = c }
+}
+
+// withJobsCache makes a /jobs handler serve the pipeline states the poller
+// wrote to c.
+func withJobsCache(c *jobsCache) HandlerOption {
+	return func(o *handlerOptions) { o.jobsCache = c }
+}
+
+func newHandlerOptions(opts []HandlerOption) handlerOptions {
+	var o handlerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// results returns the results of targets, in their order: from the cache in
+// polling mode, otherwise by running the checks.
+func (o *handlerOptions) results(ctx context.Context, timeout time.Duration, targets []checkTarget) []checkResult {
+	if o.cache != nil {
+		return o.cache.load(targets)
+	}
+
+	results := runChecks(ctx, timeout, o.checkConcurrency, targets)
+	o.metrics.observe(o.probe, targets, results)
+	traceChecks(ctx, o.probe, targets, results)
+
+	return results
+}
+
+// critical reports whether a failure of the named plugin fails the probe.
+func (o *handlerOptions) critical(name string) bool {
+	_, ok := o.nonCritical[name]
+	return !ok
+}
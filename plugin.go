@@ -5,7 +5,9 @@ import (
 	stderr "errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,15 +16,36 @@ import (
 	"github.com/roadrunner-server/api-plugins/v6/status"
 	"github.com/roadrunner-server/endure/v2/dep"
 	"github.com/roadrunner-server/errors"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
-// errPluginNotFound is returned (wrapped) by status/ready when the requested plugin is not registered.
-var errPluginNotFound = stderr.New("no such plugin")
+var (
+	// errPluginNotFound is returned (wrapped) by status/ready when the requested plugin is not registered.
+	errPluginNotFound = stderr.New("no such plugin")
+	// errJobsNotFound is returned by jobs when no JobsChecker is registered.
+	errJobsNotFound = stderr.New("jobs plugin not found")
+	// errUnknownProbe is returned (wrapped) for a probe type other than health or ready.
+	errUnknownProbe = stderr.New("unknown probe")
+	// errNotServing is returned by probe before Serve built the handlers.
+	errNotServing = stderr.New("status plugin is not serving yet")
+	// errStuckReadyDisabled is returned by Validate for jobs.stuck_ready without
+	// the stuck detection it relies on.
+	errStuckReadyDisabled = stderr.New("jobs.stuck_ready requires jobs.stuck_after or jobs.not_ready_grace")
+)
 
 const (
 	// PluginName declares public plugin name.
 	PluginName          = "status"
 	pluginsQuery string = "plugin"
+
+	// probe types, as named in the rpc requests and listed by /plugins
+	probeHealth  = "health"
+	probeReady   = "ready"
+	probeStartup = "startup"
+	probeJobs    = "jobs"
 )
 
 type Configurer interface {
@@ -61,13 +84,43 @@ type Plugin struct {
 	statusRegistry map[string]Checker
 	// plugins that need to send Readiness status
 	readyRegistry map[string]Readiness
-	// jobs plugin checker
-	statusJobsRegistry JobsChecker
+	// plugins reporting the state of their job pipelines
+	statusJobsRegistry map[string]JobsChecker
 	// true once Stop is called; checked by all HTTP handlers
 	shutdownInitiated atomic.Bool
 	server            *http.Server
 	log               *slog.Logger
 	cfg               *Config
+	// share the running Status and Ready calls between http and rpc callers
+	healthFlights *coalescer
+	readyFlights  *coalescer
+	// latest check results served by /health and /ready
+	healthHistory *history
+	readyHistory  *history
+	// the collected plugins, served by /plugins
+	catalog *catalog
+	// the collectors served by /metrics
+	metrics *metrics
+	// exports the spans of the probe requests, nil unless tracing is configured
+	tracerProvider *sdktrace.TracerProvider
+	// the grpc.health.v1.Health server, nil unless grpc.address is set
+	grpcServer *grpc.Server
+	grpcHealth *grpcHealth
+	// manual switch failing /ready, toggled over rpc or POST /maintenance
+	maintenance *maintenance
+	// shutdown start and progress, reported by every endpoint while draining
+	drain *drain
+	// the /health and /ready handlers, shared with the StatusAll and ReadyAll rpc
+	// methods; nil until Serve
+	healthHandler *Health
+	readyHandler  *Ready
+	// background checks, nil unless poll_interval is set
+	poller *poller
+	// background sampling of the job pipelines, nil unless jobs.sample_interval is set
+	sampler *sampler
+	// flags the job pipelines whose consumers hang, nil unless jobs.stuck_after or
+	// jobs.not_ready_grace is set
+	stuck *stuckDetector
 }
 
 func (c *Plugin) Init(cfg Configurer, log Logger) error {
@@ -83,21 +136,160 @@ func (c *Plugin) Init(cfg Configurer, log Logger) error {
 	// init defaults for the status plugin
 	c.cfg.InitDefaults()
 
+	err = c.cfg.Validate()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if c.cfg.Tracing != nil && c.cfg.Tracing.Endpoint != "" {
+		c.tracerProvider, err = newTracerProvider(c.cfg.Tracing)
+		if err != nil {
+			return errors.E(op, err)
+		}
+	}
+
 	c.readyRegistry = make(map[string]Readiness)
 	c.statusRegistry = make(map[string]Checker)
+	c.statusJobsRegistry = make(map[string]JobsChecker)
+
+	c.healthFlights = newCoalescer(c.cfg.ResultTTL)
+	c.readyFlights = newCoalescer(c.cfg.ResultTTL)
+	c.healthHistory = newHistory(c.cfg.HistorySize)
+	c.readyHistory = newHistory(c.cfg.HistorySize)
 
 	c.log = log.NamedLogger(PluginName)
+	c.maintenance = newMaintenance(c.log)
+	c.stuck = c.cfg.stuckDetector()
+	c.metrics = newMetrics(&c.shutdownInitiated)
+	c.catalog = &catalog{
+		statusRegistry: c.statusRegistry,
+		readyRegistry:  c.readyRegistry,
+		jobsRegistry:   c.statusJobsRegistry,
+		startupPlugins: c.cfg.StartupPlugins,
+		nonCritical:    c.cfg.nonCriticalPlugins(),
+	}
+	c.drain = &drain{
+		timeout:        c.cfg.checkTimeout(),
+		concurrency:    c.cfg.CheckConcurrency,
+		statusRegistry: c.statusRegistry,
+		readyRegistry:  c.readyRegistry,
+		healthFlights:  c.healthFlights,
+		readyFlights:   c.readyFlights,
+		healthHistory:  c.healthHistory,
+		readyHistory:   c.readyHistory,
+	}
 
 	return nil
 }
 
 func (c *Plugin) Serve() chan error {
-	errCh := make(chan error, 1)
+	// one for the http and one for the grpc server
+	errCh := make(chan error, 2)
+
+	opts := []HandlerOption{
+		WithCheckTimeout(c.cfg.checkTimeout()),
+		WithCheckConcurrency(c.cfg.CheckConcurrency),
+		WithNonCriticalPlugins(c.cfg.nonCriticalPlugins()...),
+		WithStrictPlugins(c.cfg.StrictPlugins),
+		WithServiceID(c.cfg.ServiceID),
+		withDrain(c.drain),
+	}
+
+	// every handler keeps its own threshold state
+	healthOpts := slices.Concat(opts, []HandlerOption{withCoalescer(c.healthFlights), WithThresholds(c.cfg.thresholds()), withHistory(c.healthHistory), withMetrics(c.metrics, probeHealth)})
+	readyOpts := slices.Concat(opts, []HandlerOption{withCoalescer(c.readyFlights), WithThresholds(c.cfg.thresholds()), withHistory(c.readyHistory), withMaintenance(c.maintenance), withMetrics(c.metrics, probeReady)})
+	// the startup checks share the Ready calls, but not the thresholds, history
+	// and maintenance of /ready
+	startupOpts := slices.Concat(opts, []HandlerOption{withCoalescer(c.readyFlights), WithStartupPlugins(c.cfg.StartupPlugins...), WithStartupGracePeriod(c.cfg.StartupGracePeriod), withMetrics(c.metrics, probeStartup)})
+	jobsOpts := opts
+	if c.cfg.MaintenanceJobs {
+		jobsOpts = slices.Concat(opts, []HandlerOption{withMaintenance(c.maintenance)})
+	}
+	// the latest pipeline states, exported by /metrics instead of fresh ones
+	var jobsLatest *jobsCache
+
+	if c.cfg.Jobs != nil {
+		jobsOpts = slices.Concat(jobsOpts, []HandlerOption{WithJobsRules(c.cfg.Jobs.Pipelines, c.cfg.Jobs.Drivers), withStuckDetector(c.stuck)})
+
+		if c.cfg.Jobs.SampleInterval > 0 && len(c.statusJobsRegistry) > 0 {
+			s := newSampler(c.log, c.statusJobsRegistry, c.cfg.Jobs.SampleInterval, c.cfg.checkTimeout(), c.cfg.Jobs.SampleSize)
+			s.stuck = c.stuck
+			jobsOpts = append(jobsOpts, withSampler(s))
+			jobsLatest = s.latest
+
+			c.mu.Lock()
+			c.sampler = s
+			c.mu.Unlock()
+
+			go s.run()
+		}
+
+		if (c.cfg.Jobs.Ready || c.cfg.Jobs.StuckReady) && len(c.statusJobsRegistry) > 0 {
+			jr := &jobsReadiness{
+				log:      c.log,
+				registry: c.statusJobsRegistry,
+				timeout:  c.cfg.checkTimeout(),
+			}
+			if c.cfg.Jobs.Ready {
+				jr.rules = c.cfg.jobsRules()
+			}
+			if c.cfg.Jobs.StuckReady {
+				jr.stuck = c.stuck
+			}
+
+			c.readyRegistry[jobsReadinessName] = jr
+		}
+	}
+
+	if c.cfg.PollInterval > 0 {
+		p := c.newPoller()
+		healthOpts = append(healthOpts, withResultCache(p.health))
+		readyOpts = append(readyOpts, withResultCache(p.ready))
+		startupOpts = append(startupOpts, withResultCache(p.ready))
+		jobsOpts = slices.Concat(jobsOpts, []HandlerOption{withJobsCache(p.jobs)})
+		jobsLatest = p.jobs
+
+		c.mu.Lock()
+		c.poller = p
+		c.mu.Unlock()
+
+		go p.run()
+	}
+
+	c.metrics.exportJobs(c.jobsMetrics(jobsLatest))
+
+	health := NewHealthHandler(c.statusRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode, healthOpts...)
+	ready := NewReadyHandler(c.readyRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode, readyOpts...)
+
+	c.mu.Lock()
+	c.healthHandler, c.readyHandler = health, ready
+	c.mu.Unlock()
+
+	// a nil *sdktrace.TracerProvider must not become a non-nil interface
+	var tp trace.TracerProvider
+	if c.tracerProvider != nil {
+		tp = c.tracerProvider
+	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/health", NewHealthHandler(c.statusRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode))
-	mux.Handle("/ready", NewReadyHandler(c.readyRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode))
-	mux.Handle("/jobs", NewJobsHandler(c.statusJobsRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode))
+	mux.Handle("/health", traced(tp, health))
+	mux.Handle("/ready", traced(tp, ready))
+	mux.Handle("/startup", NewStartupHandler(c.readyRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode, startupOpts...))
+	mux.Handle("/livez", livez(health))
+	mux.Handle("/livez/{"+kubezPluginPath+"}", livez(health))
+	mux.Handle("/readyz", readyz(ready))
+	mux.Handle("/readyz/{"+kubezPluginPath+"}", readyz(ready))
+	mux.Handle("/health/history", &historyHandler{log: c.log, history: c.healthHistory})
+	mux.Handle("/ready/history", &historyHandler{log: c.log, history: c.readyHistory})
+	jobs := NewMultiJobsHandler(c.statusJobsRegistry, &c.shutdownInitiated, c.log, c.cfg.UnavailableStatusCode, jobsOpts...)
+	mux.Handle("/jobs", traced(tp, jobs))
+	mux.Handle("/jobs/{"+pipelinePath+"}", traced(tp, jobs))
+	mux.Handle("/jobs/history", &jobsHistoryHandler{log: c.log, sampler: c.sampler})
+	mux.Handle("/plugins", &pluginsHandler{log: c.log, catalog: c.catalog})
+	mux.Handle("/metrics", c.metrics.handler())
+	if c.cfg.MaintenanceToken != "" {
+		mux.Handle("/maintenance", &maintenanceHandler{log: c.log, maintenance: c.maintenance, token: c.cfg.MaintenanceToken})
+	}
 
 	c.mu.Lock()
 	c.server = &http.Server{
@@ -122,23 +314,140 @@ func (c *Plugin) Serve() chan error {
 		}
 	}()
 
+	if c.cfg.GRPC != nil && c.cfg.GRPC.Address != "" {
+		c.serveGRPC(errCh)
+	}
+
 	return errCh
 }
 
-func (c *Plugin) Stop(_ context.Context) error {
+// serveGRPC starts the grpc.health.v1.Health server on the configured address,
+// reporting its errors on errCh.
+func (c *Plugin) serveGRPC(errCh chan error) {
+	ln, err := net.Listen("tcp", c.cfg.GRPC.Address)
+	if err != nil {
+		errCh <- err
+		return
+	}
+
+	gh := newGRPCHealth(c, c.cfg.GRPC.WatchInterval)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, gh)
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.grpcServer, c.grpcHealth = srv, gh
+	c.mu.Unlock()
+
+	go func() {
+		err := srv.Serve(ln)
+		if err != nil && !stderr.Is(err, grpc.ErrServerStopped) {
+			errCh <- err
+		}
+	}()
+}
+
+// Stop drains the plugin: /ready and /jobs fail for the configured shutdown
+// delay, then the http server shuts down, letting the in-flight probes finish
+// until ctx is done.
+func (c *Plugin) Stop(ctx context.Context) error {
+	const op = errors.Op("checker_plugin_stop")
 
 	// set shutdown to true: /ready and /jobs then return the configured unavailable
 	// status code, while /health (liveness) stays 200 so the orchestrator does not
 	// kill the draining process
+	c.drain.begin()
+	defer c.drain.end()
 	c.shutdownInitiated.Store(true)
 
+	if c.cfg != nil && c.cfg.ShutdownDelay > 0 {
+		c.log.Info("draining before the http server shuts down", "delay", c.cfg.ShutdownDelay)
+
+		timer := time.NewTimer(c.cfg.ShutdownDelay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	// the handlers and rpc methods still running take c.mu, do not hold it
+	// while waiting for them
+	c.mu.Lock()
+	server, grpcServer, gh := c.server, c.grpcServer, c.grpcHealth
+	p, s, tp := c.poller, c.sampler, c.tracerProvider
+	c.mu.Unlock()
+
+	if p != nil {
+		p.stop()
+	}
+
+	if s != nil {
+		s.stop()
+	}
+
+	// the watches end at once, the running checks until ctx is done
+	if grpcServer != nil {
+		gh.stop()
+
+		done := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+
+	// flush the spans of the last probes
+	if tp != nil {
+		defer func() {
+			if err := tp.Shutdown(ctx); err != nil {
+				c.log.Warn("failed to export the remaining spans", "error", err)
+			}
+		}()
+	}
+
+	if server == nil {
+		return nil
+	}
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		// the probes did not finish in time, drop them
+		_ = server.Close()
+		return errors.E(op, err)
+	}
+
 	return nil
 }
 
+// newPoller returns a poller over the collected plugins, with the timing of the
+// configuration.
+func (c *Plugin) newPoller() *poller {
+	return &poller{
+		log:            c.log,
+		interval:       c.cfg.PollInterval,
+		timeout:        c.cfg.checkTimeout(),
+		concurrency:    c.cfg.CheckConcurrency,
+		statusRegistry: c.statusRegistry,
+		readyRegistry:  c.readyRegistry,
+		jobsRegistry:   c.statusJobsRegistry,
+		health:         newResultCache(c.cfg.MaxResultAge),
+		ready:          newResultCache(c.cfg.MaxResultAge),
+		jobs:           &jobsCache{maxAge: c.cfg.MaxResultAge},
+		stuck:          c.stuck,
+		metrics:        c.metrics,
+		stopCh:         make(chan struct{}),
+	}
+}
+
 // status looks up the named plugin in the status registry and delegates to its
-// Checker.Status. Returns errPluginNotFound (wrapped) if the name is not
+// Checker.Status under the configured check timeout, sharing a running call with
+// the /health handler. Returns errPluginNotFound (wrapped) if the name is not
 // registered.
 func (c *Plugin) status(name string) (*status.Status, error) {
 	svc, ok := c.statusRegistry[name]
@@ -146,11 +455,14 @@ func (c *Plugin) status(name string) (*status.Status, error) {
 		return nil, fmt.Errorf("%w: %s", errPluginNotFound, name)
 	}
 
-	return svc.Status()
+	res := runCheck(context.Background(), c.cfg.checkTimeout(), c.healthFlights.wrap(name, svc.Status))
+
+	return res.st, res.err
 }
 
 // ready looks up the named plugin in the readiness registry and delegates to
-// its Readiness.Ready. Returns errPluginNotFound (wrapped) if the name is not
+// its Readiness.Ready under the configured check timeout, sharing a running call
+// with the /ready handler. Returns errPluginNotFound (wrapped) if the name is not
 // registered.
 func (c *Plugin) ready(name string) (*status.Status, error) {
 	svc, ok := c.readyRegistry[name]
@@ -158,7 +470,136 @@ func (c *Plugin) ready(name string) (*status.Status, error) {
 		return nil, fmt.Errorf("%w: %s", errPluginNotFound, name)
 	}
 
-	return svc.Ready()
+	res := runCheck(context.Background(), c.cfg.checkTimeout(), c.readyFlights.wrap(name, svc.Ready))
+
+	return res.st, res.err
+}
+
+// probe returns the aggregated report of the given probe type, "health" or
+// "ready", for the named plugins or all of them: the same reports, thresholds
+// and history as the /health and /ready handlers, under the configured check
+// timeout or the deadline of ctx, whichever comes first.
+func (c *Plugin) probe(ctx context.Context, probe string, names []string) (*ProbeResponse, error) {
+	c.mu.Lock()
+	health, ready := c.healthHandler, c.readyHandler
+	c.mu.Unlock()
+
+	if health == nil || ready == nil {
+		return nil, errNotServing
+	}
+
+	usc := c.cfg.UnavailableStatusCode
+	resp := &ProbeResponse{Code: http.StatusOK, Reports: []*Report{}}
+
+	switch probe {
+	case probeHealth:
+		// liveness stays up during the shutdown, as on /health
+		if c.shutdownInitiated.Load() {
+			resp.Status, resp.Message = SeverityPass, shutdownMessage
+			return resp, nil
+		}
+
+		resp.Reports = health.check(ctx, c.cfg.checkTimeout(), names)
+		resp.Status = health.opts.evaluate(resp.Reports, usc)
+	case probeReady:
+		if c.shutdownInitiated.Load() {
+			resp.Status, resp.Code, resp.Message = SeverityFail, usc, shutdownMessage
+			return resp, nil
+		}
+
+		if st := c.maintenance.state(); st.On {
+			resp.Status, resp.Code, resp.Message = SeverityFail, usc, st.message()
+			return resp, nil
+		}
+
+		resp.Reports = ready.check(ctx, c.cfg.checkTimeout(), names)
+		resp.Status = ready.opts.evaluate(resp.Reports, usc)
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownProbe, probe)
+	}
+
+	if resp.Status == SeverityFail {
+		resp.Code = usc
+	}
+
+	return resp, nil
+}
+
+// jobs returns the reports of the job pipelines selected by jq and the number of
+// selected pipelines: from the poller in polling mode, otherwise from every
+// JobsChecker under the configured check timeout.
+func (c *Plugin) jobs(jq jobsQuery) ([]*JobsReport, int, error) {
+	if len(c.statusJobsRegistry) == 0 {
+		return nil, 0, errJobsNotFound
+	}
+
+	c.mu.Lock()
+	p, s := c.poller, c.sampler
+	c.mu.Unlock()
+
+	var (
+		results   []jobsResult
+		checkedAt time.Time
+		err       error
+	)
+
+	if p != nil {
+		results, checkedAt, err = p.jobs.load()
+		if err != nil {
+			return nil, 0, err
+		}
+	} else {
+		results, checkedAt = collectJobs(context.Background(), c.statusJobsRegistry, c.cfg.checkTimeout()), time.Now()
+	}
+
+	// a provider that failed is reported as such, unless all of them did
+	if !slices.ContainsFunc(results, func(res jobsResult) bool { return res.err == nil }) {
+		return nil, 0, jobsErr(results)
+	}
+
+	report := jobsReports(results, checkedAt, c.cfg.jobsRules())
+	s.annotate(report)
+	c.stuck.observe(results, checkedAt)
+	c.stuck.annotate(report)
+
+	page, total := jq.apply(report)
+
+	return page, total, nil
+}
+
+// jobsMetrics returns the pipelines /metrics exports: the latest state in
+// cache, if any, otherwise a fresh one under the configured check timeout. It
+// takes no lock of the plugin, so a scrape never waits for Stop.
+func (c *Plugin) jobsMetrics(cache *jobsCache) func() []*JobsReport {
+	return func() []*JobsReport {
+		if len(c.statusJobsRegistry) == 0 {
+			return nil
+		}
+
+		if cache == nil {
+			return jobsReports(collectJobs(context.Background(), c.statusJobsRegistry, c.cfg.checkTimeout()), time.Now(), c.cfg.jobsRules())
+		}
+
+		results, checkedAt, err := cache.load()
+		if err != nil {
+			return nil
+		}
+
+		return jobsReports(results, checkedAt, c.cfg.jobsRules())
+	}
+}
+
+// history returns the recorded history of the given probe type, "health" or
+// "ready", for the named plugins or all of them.
+func (c *Plugin) history(probe string, names []string) ([]HistoryEntry, error) {
+	switch probe {
+	case probeHealth:
+		return c.healthHistory.entries(names), nil
+	case probeReady:
+		return c.readyHistory.entries(names), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownProbe, probe)
+	}
 }
 
 // Collects declare services to be collected.
@@ -173,7 +614,8 @@ func (c *Plugin) Collects() []*dep.In {
 			c.statusRegistry[s.Name()] = s
 		}, (*Checker)(nil)),
 		dep.Fits(func(p any) {
-			c.statusJobsRegistry = p.(JobsChecker)
+			j := p.(JobsChecker)
+			c.statusJobsRegistry[j.Name()] = j
 		}, (*JobsChecker)(nil)),
 	}
 }
@@ -186,6 +628,23 @@ func (c *Plugin) StopHTTPServer() {
 	if c.server != nil {
 		_ = c.server.Close()
 	}
+
+	if c.poller != nil {
+		c.poller.stop()
+	}
+
+	if c.sampler != nil {
+		c.sampler.stop()
+	}
+
+	if c.grpcServer != nil {
+		c.grpcHealth.stop()
+		c.grpcServer.Stop()
+	}
+
+	if c.tracerProvider != nil {
+		_ = c.tracerProvider.Shutdown(context.Background())
+	}
 }
 
 // Name of the service.
@@ -4,9 +4,12 @@ import (
 	stderr "errors"
 	"log/slog"
 	"net"
+	"net/http"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
 	"github.com/roadrunner-server/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -96,3 +99,183 @@ func TestPluginUnknownPlugin(t *testing.T) {
 	_, err = p.ready("nonexistent")
 	require.ErrorIs(t, err, errPluginNotFound)
 }
+
+// TestPluginCheckIsolated checks that the lookups the rpc service uses survive a
+// panicking plugin instead of taking the process down.
+func TestPluginCheckIsolated(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true}, initLogger{}))
+
+	p.statusRegistry["http"] = &mockChecker{name: "http", panicVal: "nil map"}
+	p.readyRegistry["http"] = &mockReadiness{name: "http", panicVal: "nil map"}
+
+	_, err := p.status("http")
+	require.ErrorIs(t, err, errCheckPanic)
+
+	_, err = p.ready("http")
+	require.ErrorIs(t, err, errCheckPanic)
+}
+
+// TestPluginStopDrains checks that Stop keeps failing /ready for the shutdown
+// delay and closes the listener afterwards.
+func TestPluginStopDrains(t *testing.T) {
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true, cfg: &Config{Address: addr, ShutdownDelay: time.Millisecond * 500}}, initLogger{}))
+	_ = p.Serve()
+
+	// a fresh connection per probe, an unused pooled one would hold up the shutdown
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func() (int, error) {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+addr+"/ready", nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		_ = resp.Body.Close()
+
+		return resp.StatusCode, nil
+	}
+
+	require.Eventually(t, func() bool {
+		code, err := get()
+		return err == nil && code == http.StatusOK
+	}, time.Second*5, time.Millisecond*10)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- p.Stop(t.Context()) }()
+
+	require.Eventually(t, func() bool {
+		code, err := get()
+		return err == nil && code == http.StatusServiceUnavailable
+	}, time.Second*5, time.Millisecond*10)
+
+	select {
+	case err := <-stopped:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("stop did not return after the shutdown delay")
+	}
+
+	_, err = get()
+	require.Error(t, err)
+}
+
+// TestPluginStopUnlocked checks that the rpc methods answer while Stop waits for
+// the in-flight probes.
+func TestPluginStopUnlocked(t *testing.T) {
+	var lc net.ListenConfig
+
+	ln, err := lc.Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true, cfg: &Config{Address: addr}}, initLogger{}))
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	p.readyRegistry["http"] = &countingReadiness{calls: &calls, release: release}
+	_ = p.Serve()
+	r := &rpc{srv: p, log: p.log}
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	go func() {
+		req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "http://"+addr+"/ready", nil)
+		if err != nil {
+			return
+		}
+
+		for {
+			resp, err := client.Do(req)
+			if err == nil {
+				_ = resp.Body.Close()
+				return
+			}
+
+			time.Sleep(time.Millisecond * 10)
+		}
+	}()
+
+	// the /ready probe hangs in the plugin
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second*5, time.Millisecond)
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- p.Stop(t.Context()) }()
+	require.Eventually(t, p.shutdownInitiated.Load, time.Second, time.Millisecond)
+
+	answered := make(chan ProbeResponse, 1)
+	go func() {
+		var out ProbeResponse
+		assert.NoError(t, r.ReadyAll(&ProbeRequest{}, &out))
+		answered <- out
+	}()
+
+	select {
+	case out := <-answered:
+		assert.Equal(t, shutdownMessage, out.Message)
+	case <-time.After(time.Second * 5):
+		t.Fatal("the rpc method waited for the shutdown")
+	}
+
+	close(release)
+	require.NoError(t, <-stopped)
+}
+
+// TestPluginProbeRPC checks that StatusAll and ReadyAll return the reports of
+// /health and /ready.
+func TestPluginProbeRPC(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true, cfg: &Config{Address: "127.0.0.1:0"}}, initLogger{}))
+	r := &rpc{srv: p, log: p.log}
+
+	// errors.E does not unwrap, the sentinel is pinned on the probe itself
+	_, err := p.probe(t.Context(), probeHealth, nil)
+	require.ErrorIs(t, err, errNotServing)
+	require.ErrorContains(t, r.StatusAll(&ProbeRequest{}, &ProbeResponse{}), errNotServing.Error())
+
+	p.statusRegistry["http"] = &mockChecker{name: "http", st: &apiStatus.Status{Code: http.StatusOK}}
+	p.readyRegistry["http"] = &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}}
+	p.readyRegistry["grpc"] = &mockReadiness{name: "grpc", st: &apiStatus.Status{Code: http.StatusInternalServerError}}
+
+	p.Serve()
+	t.Cleanup(p.StopHTTPServer)
+
+	var out ProbeResponse
+	require.NoError(t, r.StatusAll(&ProbeRequest{}, &out))
+	assert.Equal(t, SeverityPass, out.Status)
+	assert.Equal(t, http.StatusOK, out.Code)
+	require.Len(t, out.Reports, 1)
+	assert.Equal(t, "http", out.Reports[0].PluginName)
+
+	out = ProbeResponse{}
+	require.NoError(t, r.ReadyAll(&ProbeRequest{}, &out))
+	assert.Equal(t, SeverityFail, out.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, out.Code)
+	require.Len(t, out.Reports, 2)
+
+	out = ProbeResponse{}
+	require.NoError(t, r.ReadyAll(&ProbeRequest{Plugins: []string{"grpc"}}, &out))
+	require.Len(t, out.Reports, 1)
+	assert.Equal(t, "internal server error, see logs", out.Reports[0].ErrorMessage)
+	assert.Equal(t, SeverityFail, out.Reports[0].Severity)
+
+	// the checks are recorded like the ones of /ready
+	assert.NotEmpty(t, p.readyHistory.entries([]string{"grpc"}))
+
+	p.maintenance.set(true, "upgrade")
+	out = ProbeResponse{}
+	require.NoError(t, r.ReadyAll(&ProbeRequest{}, &out))
+	assert.Equal(t, http.StatusServiceUnavailable, out.Code)
+	assert.Contains(t, out.Message, "upgrade")
+	assert.Empty(t, out.Reports)
+}
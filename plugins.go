@@ -0,0 +1,98 @@
+package status
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// interfaces a collected plugin may implement, as listed by /plugins
+const (
+	interfaceChecker     = "Checker"
+	interfaceReadiness   = "Readiness"
+	interfaceJobsChecker = "JobsChecker"
+)
+
+// PluginInfo describes a collected plugin, as served by /plugins.
+type PluginInfo struct {
+	Name string `json:"name"`
+	// Interfaces the plugin implements: Checker, Readiness and JobsChecker.
+	Interfaces []string `json:"interfaces"`
+	// Probes the plugin takes part in: health, ready, startup and jobs.
+	Probes []string `json:"probes"`
+	// Critical is false for a plugin whose failures only warn.
+	Critical bool `json:"critical"`
+}
+
+// catalog lists the collected plugins and what they take part in.
+type catalog struct {
+	statusRegistry map[string]Checker
+	readyRegistry  map[string]Readiness
+	jobsRegistry   map[string]JobsChecker
+	// the plugins /startup waits for, all Readiness plugins if empty
+	startupPlugins []string
+	nonCritical    []string
+}
+
+// list returns every collected plugin ordered by name.
+func (ct *catalog) list() []PluginInfo {
+	names := slices.Concat(
+		slices.Collect(maps.Keys(ct.statusRegistry)),
+		slices.Collect(maps.Keys(ct.readyRegistry)),
+		slices.Collect(maps.Keys(ct.jobsRegistry)),
+	)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	out := make([]PluginInfo, 0, len(names))
+	for _, name := range names {
+		pi := PluginInfo{
+			Name:       name,
+			Interfaces: []string{},
+			Probes:     []string{},
+			Critical:   !slices.Contains(ct.nonCritical, name),
+		}
+
+		if _, ok := ct.statusRegistry[name]; ok {
+			pi.Interfaces = append(pi.Interfaces, interfaceChecker)
+			pi.Probes = append(pi.Probes, probeHealth)
+		}
+		if _, ok := ct.readyRegistry[name]; ok {
+			pi.Interfaces = append(pi.Interfaces, interfaceReadiness)
+			pi.Probes = append(pi.Probes, probeReady)
+			if len(ct.startupPlugins) == 0 || slices.Contains(ct.startupPlugins, name) {
+				pi.Probes = append(pi.Probes, probeStartup)
+			}
+		}
+		if _, ok := ct.jobsRegistry[name]; ok {
+			pi.Interfaces = append(pi.Interfaces, interfaceJobsChecker)
+			pi.Probes = append(pi.Probes, probeJobs)
+		}
+
+		out = append(out, pi)
+	}
+
+	return out
+}
+
+// pluginsHandler serves the collected plugins, so a client can tell which names
+// ?plugin= accepts.
+type pluginsHandler struct {
+	log     *slog.Logger
+	catalog *catalog
+}
+
+func (ph *pluginsHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	data, err := json.Marshal(ph.catalog.list())
+	if err != nil {
+		ph.log.Error("failed to marshal plugins", "error", err)
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		ph.log.Error("failed to write plugins", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	ct := &catalog{
+		statusRegistry: map[string]Checker{"http": &mockChecker{name: "http"}},
+		readyRegistry: map[string]Readiness{
+			"http": &mockReadiness{name: "http"},
+			"grpc": &mockReadiness{name: "grpc"},
+		},
+		jobsRegistry:   map[string]JobsChecker{"jobs": &mockJobsChecker{}},
+		startupPlugins: []string{"http"},
+		nonCritical:    []string{"grpc"},
+	}
+
+	assert.Equal(t, []PluginInfo{
+		{Name: "grpc", Interfaces: []string{"Readiness"}, Probes: []string{"ready"}, Critical: false},
+		{Name: "http", Interfaces: []string{"Checker", "Readiness"}, Probes: []string{"health", "ready", "startup"}, Critical: true},
+		{Name: "jobs", Interfaces: []string{"JobsChecker"}, Probes: []string{"jobs"}, Critical: true},
+	}, ct.list())
+
+	t.Run("Handler", func(t *testing.T) {
+		ph := &pluginsHandler{log: slog.New(slog.DiscardHandler), catalog: ct}
+		rec := httptest.NewRecorder()
+		ph.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/plugins", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var plugins []PluginInfo
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &plugins))
+		assert.Equal(t, ct.list(), plugins)
+
+		// nothing collected
+		ph = &pluginsHandler{log: slog.New(slog.DiscardHandler), catalog: &catalog{}}
+		rec = httptest.NewRecorder()
+		ph.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/plugins", nil))
+		assert.JSONEq(t, "[]", rec.Body.String())
+	})
+
+	t.Run("RPC", func(t *testing.T) {
+		p := &Plugin{log: slog.New(slog.DiscardHandler), catalog: ct}
+		r := &rpc{srv: p, log: p.log}
+
+		var out PluginsResponse
+		require.NoError(t, r.Plugins(&PluginsRequest{}, &out))
+		assert.Equal(t, ct.list(), out.Plugins)
+	})
+}
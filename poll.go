@@ -0,0 +1,202 @@
+package status
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var (
+	// errNotChecked is returned (wrapped) for a plugin the poller has not checked yet.
+	errNotChecked = stderr.New("plugin has not been checked yet")
+	// errStaleResult is returned (wrapped) for a cached result older than the max result age.
+	errStaleResult = stderr.New("cached result is stale")
+)
+
+// resultCache keeps the latest Status or Ready result of every plugin, as
+// written by the poller.
+type resultCache struct {
+	mu      sync.RWMutex
+	results map[string]checkResult
+	maxAge  time.Duration
+}
+
+func newResultCache(maxAge time.Duration) *resultCache {
+	return &resultCache{
+		results: make(map[string]checkResult),
+		maxAge:  maxAge,
+	}
+}
+
+func (c *resultCache) store(targets []checkTarget, results []checkResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, t := range targets {
+		c.results[t.name] = results[i]
+	}
+}
+
+// load returns the cached results of targets, in their order. A plugin that was
+// never checked gets errNotChecked, a result older than maxAge keeps its
+// timestamp but gets errStaleResult.
+func (c *resultCache) load(targets []checkTarget) []checkResult {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	results := make([]checkResult, len(targets))
+	for i, t := range targets {
+		res, ok := c.results[t.name]
+		switch {
+		case !ok:
+			results[i] = checkResult{err: errNotChecked}
+		case c.maxAge > 0 && time.Since(res.checkedAt) > c.maxAge:
+			results[i] = checkResult{
+				err:       fmt.Errorf("%w: last checked %s ago", errStaleResult, time.Since(res.checkedAt).Round(time.Millisecond)),
+				checkedAt: res.checkedAt,
+			}
+		default:
+			results[i] = res
+		}
+	}
+
+	return results
+}
+
+// jobsCache keeps the latest JobsState results, as written by the poller.
+type jobsCache struct {
+	mu        sync.RWMutex
+	results   []jobsResult
+	checkedAt time.Time
+	maxAge    time.Duration
+}
+
+func (c *jobsCache) store(results []jobsResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.results, c.checkedAt = results, time.Now()
+}
+
+// load returns the cached results and the time they were taken, with the same
+// errNotChecked and errStaleResult semantics as resultCache.load.
+func (c *jobsCache) load() ([]jobsResult, time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	switch {
+	case c.checkedAt.IsZero():
+		return nil, c.checkedAt, errNotChecked
+	case c.maxAge > 0 && time.Since(c.checkedAt) > c.maxAge:
+		return nil, c.checkedAt, fmt.Errorf("%w: last checked %s ago", errStaleResult, time.Since(c.checkedAt).Round(time.Millisecond))
+	default:
+		return c.results, c.checkedAt, nil
+	}
+}
+
+// poller checks every registered plugin on a fixed interval and writes the
+// results to the caches the handlers serve in polling mode.
+type poller struct {
+	log         *slog.Logger
+	interval    time.Duration
+	timeout     time.Duration
+	concurrency int
+
+	statusRegistry map[string]Checker
+	readyRegistry  map[string]Readiness
+	jobsRegistry   map[string]JobsChecker
+
+	health *resultCache
+	ready  *resultCache
+	jobs   *jobsCache
+	stuck  *stuckDetector
+	// exports the durations of the checks, may be nil
+	metrics *metrics
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// run polls until stop is called. The first round runs immediately, a round
+// that takes longer than the interval delays the next one instead of overlapping
+// with it.
+func (p *poller) run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll()
+
+		select {
+		case <-ticker.C:
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+func (p *poller) stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+	})
+}
+
+// poll runs a single round over the status, readiness and jobs checks.
+func (p *poller) poll() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// abort the round on stop instead of waiting for the check timeout
+	go func() {
+		select {
+		case <-p.stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		targets := make([]checkTarget, 0, len(p.statusRegistry))
+		for name, pl := range p.statusRegistry {
+			if pl != nil {
+				targets = append(targets, checkTarget{name: name, check: timed(pl.Status)})
+			}
+		}
+
+		results := runChecks(ctx, p.timeout, p.concurrency, targets)
+		p.metrics.observe(probeHealth, targets, results)
+		p.health.store(targets, results)
+	})
+
+	wg.Go(func() {
+		targets := make([]checkTarget, 0, len(p.readyRegistry))
+		for name, pl := range p.readyRegistry {
+			if pl != nil {
+				targets = append(targets, checkTarget{name: name, check: timed(pl.Ready)})
+			}
+		}
+
+		results := runChecks(ctx, p.timeout, p.concurrency, targets)
+		p.metrics.observe(probeReady, targets, results)
+		p.ready.store(targets, results)
+	})
+
+	if len(p.jobsRegistry) > 0 {
+		wg.Go(func() {
+			results := collectJobs(ctx, p.jobsRegistry, p.timeout)
+			if err := jobsErr(results); err != nil {
+				p.log.Error("jobs state", "error", err)
+			}
+
+			p.jobs.store(results)
+			p.stuck.observe(results, time.Now())
+		})
+	}
+
+	wg.Wait()
+}
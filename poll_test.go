@@ -0,0 +1,183 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPoller(sr map[string]Checker, rr map[string]Readiness, jc *mockJobsChecker, maxAge time.Duration) *poller {
+	return &poller{
+		log:            slog.New(slog.DiscardHandler),
+		interval:       time.Hour,
+		timeout:        time.Second,
+		statusRegistry: sr,
+		readyRegistry:  rr,
+		jobsRegistry:   jobsRegistry(jc),
+		health:         newResultCache(maxAge),
+		ready:          newResultCache(maxAge),
+		jobs:           &jobsCache{maxAge: maxAge},
+		stopCh:         make(chan struct{}),
+	}
+}
+
+func TestResultCache(t *testing.T) {
+	targets := []checkTarget{{name: "http"}, {name: "grpc"}}
+
+	t.Run("NotChecked", func(t *testing.T) {
+		c := newResultCache(time.Minute)
+		c.store(targets[:1], []checkResult{{st: &apiStatus.Status{Code: 200}, checkedAt: time.Now()}})
+
+		results := c.load(targets)
+		require.Len(t, results, 2)
+		require.NoError(t, results[0].err)
+		assert.Equal(t, 200, results[0].st.Code)
+		require.ErrorIs(t, results[1].err, errNotChecked)
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		c := newResultCache(time.Minute)
+		checkedAt := time.Now().Add(-time.Hour)
+		c.store(targets[:1], []checkResult{{st: &apiStatus.Status{Code: 200}, checkedAt: checkedAt}})
+
+		results := c.load(targets[:1])
+		require.ErrorIs(t, results[0].err, errStaleResult)
+		assert.Nil(t, results[0].st)
+		assert.Equal(t, checkedAt, results[0].checkedAt)
+	})
+}
+
+func TestPoller(t *testing.T) {
+	sr := map[string]Checker{
+		"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+		"kv":   nil,
+	}
+	rr := map[string]Readiness{
+		"http": &mockReadiness{name: "http", err: errors.New("no workers")},
+	}
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "pipe1", Ready: true}}}
+
+	p := newTestPoller(sr, rr, jc, time.Minute)
+	p.poll()
+
+	health := p.health.load([]checkTarget{{name: "http"}, {name: "kv"}})
+	require.NoError(t, health[0].err)
+	assert.Equal(t, 200, health[0].st.Code)
+	assert.False(t, health[0].checkedAt.IsZero())
+	// nil plugins are reported by the handler, not polled
+	require.ErrorIs(t, health[1].err, errNotChecked)
+
+	ready := p.ready.load([]checkTarget{{name: "http"}})
+	require.EqualError(t, ready[0].err, "no workers")
+
+	results, checkedAt, err := p.jobs.load()
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].err)
+	require.Len(t, results[0].states, 1)
+	assert.False(t, checkedAt.IsZero())
+
+	t.Run("Stop", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			p.run()
+			close(done)
+		}()
+
+		p.stop()
+		p.stop()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second * 5):
+			t.Fatal("the poller did not stop")
+		}
+	})
+}
+
+func TestPollingHandlers(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+
+	sr := map[string]Checker{
+		"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+	}
+	rr := map[string]Readiness{
+		"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+	}
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "pipe1", Ready: true}}}
+
+	t.Run("Cached", func(t *testing.T) {
+		p := newTestPoller(sr, rr, jc, time.Minute)
+		p.poll()
+
+		// the handler must serve the polled result, not check again
+		failing := map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 500}},
+		}
+		h := NewHealthHandler(failing, newShutdownPtr(false), log, http.StatusServiceUnavailable, withResultCache(p.health))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Equal(t, 200, reports[0].StatusCode)
+		assert.False(t, reports[0].CheckedAt.IsZero())
+
+		jh := NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable, withJobsCache(p.jobs))
+		rec = httptest.NewRecorder()
+		jh.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		jobs := parseJobsReports(t, rec.Body.Bytes())
+		require.Len(t, jobs, 1)
+		assert.False(t, jobs[0].CheckedAt.IsZero())
+	})
+
+	t.Run("NotCheckedYet", func(t *testing.T) {
+		p := newTestPoller(sr, rr, jc, time.Minute)
+
+		h := NewReadyHandler(rr, newShutdownPtr(false), log, http.StatusServiceUnavailable, withResultCache(p.ready))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Equal(t, http.StatusServiceUnavailable, reports[0].StatusCode)
+		assert.Equal(t, "plugin has not been checked yet", reports[0].ErrorMessage)
+		assert.True(t, reports[0].CheckedAt.IsZero())
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		p := newTestPoller(sr, rr, jc, time.Millisecond)
+		p.poll()
+		time.Sleep(time.Millisecond * 5)
+
+		h := NewReadyHandler(rr, newShutdownPtr(false), log, http.StatusServiceUnavailable, withResultCache(p.ready))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Contains(t, reports[0].ErrorMessage, "cached result is stale")
+		assert.Positive(t, reports[0].AgeMs)
+
+		jh := NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable, withJobsCache(p.jobs))
+		rec = httptest.NewRecorder()
+		jh.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "cached result is stale")
+	})
+}
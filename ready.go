@@ -1,10 +1,11 @@
 package status
 
 import (
-	"encoding/json"
+	"context"
 	"log/slog"
 	"net/http"
 	"sync/atomic"
+	"time"
 )
 
 // readiness Handler return 200OK if all Plugins are ready to serve
@@ -15,29 +16,48 @@ type Ready struct {
 	unavailableStatusCode int
 	statusRegistry        map[string]Readiness
 	shutdownInitiated     *atomic.Bool
+	opts                  handlerOptions
 }
 
-func NewReadyHandler(sr map[string]Readiness, shutdownInitiated *atomic.Bool, log *slog.Logger, usc int) *Ready {
+func NewReadyHandler(sr map[string]Readiness, shutdownInitiated *atomic.Bool, log *slog.Logger, usc int, opts ...HandlerOption) *Ready {
 	return &Ready{
 		log:                   log,
 		statusRegistry:        sr,
 		unavailableStatusCode: usc,
 		shutdownInitiated:     shutdownInitiated,
+		opts:                  newHandlerOptions(opts),
 	}
 }
 
 func (rd *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if rd.shutdownInitiated != nil && rd.shutdownInitiated.Load() {
-		http.Error(w, "service is shutting down", rd.unavailableStatusCode)
+		rd.opts.writeShutdown(w, r, rd.log, rd.unavailableStatusCode)
 		return
 	}
 
+	if st := rd.opts.maintenance.state(); st.On {
+		rd.opts.writeMaintenance(w, r, rd.log, rd.unavailableStatusCode, st)
+		return
+	}
+
+	timeout, err := requestTimeout(r, rd.opts.checkTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rd.opts.writeReports(w, r, rd.log, rd.unavailableStatusCode, rd.check(r.Context(), timeout, r.URL.Query()[pluginsQuery]))
+}
+
+// check runs the Ready checks of the plugins in plg, or of all plugins if plg is
+// empty, each under timeout, and returns a report of each.
+func (rd *Ready) check(ctx context.Context, timeout time.Duration, plg []string) []*Report {
 	// report will be used either for all plugins or for the Plugins in the query
 	report := make([]*Report, 0, len(rd.statusRegistry))
 
-	plg := r.URL.Query()[pluginsQuery]
 	// if no Plugins provided, check them all
 	if len(plg) == 0 {
+		targets := make([]checkTarget, 0, len(rd.statusRegistry))
 		for k, pl := range rd.statusRegistry {
 			if pl == nil {
 				report = append(report, &Report{
@@ -50,8 +70,21 @@ func (rd *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
-			st, err := pl.Ready()
+			targets = append(targets, checkTarget{name: k, check: rd.opts.coalescer.wrap(k, pl.Ready)})
+		}
+
+		results := rd.opts.results(ctx, timeout, targets)
+		for i, res := range results {
+			k := targets[i].name
+			st, err := res.st, res.err
 			if err != nil {
+				if rep := abortedReport(k, err); rep != nil {
+					rd.log.Warn("check aborted", "plugin", k, "error", err)
+					rep.fail = true
+					report = append(report, rep)
+					continue
+				}
+
 				report = append(report, &Report{
 					PluginName:   k,
 					ErrorMessage: err.Error(),
@@ -73,12 +106,11 @@ func (rd *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 			switch {
 			case st.Code >= 500:
-				w.WriteHeader(rd.unavailableStatusCode)
-
 				report = append(report, &Report{
 					PluginName:   k,
 					ErrorMessage: "internal server error, see logs",
 					StatusCode:   rd.unavailableStatusCode,
+					fail:         true,
 				})
 			case st.Code >= 100 && st.Code <= 400:
 				report = append(report, &Report{
@@ -94,41 +126,51 @@ func (rd *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		data, err := json.Marshal(report)
-		if err != nil {
-			// TODO do we need to write this error to the ResponseWriter?
-			rd.log.Error("failed to marshal response", "error", err)
-			return
-		}
-
-		// write the response
-		_, err = w.Write(data)
-		if err != nil {
-			rd.log.Error("failed to write response", "error", err)
-		}
+		// the results are reported last, one report each
+		stampReports(report[len(report)-len(results):], results)
 
-		return
+		return report
 	}
 
 	// iterate over all provided Plugins
+	targets := make([]checkTarget, 0, len(plg))
 	for _, name := range plg {
 		svc, ok := rd.statusRegistry[name]
 		if !ok {
 			rd.log.Info("plugin does not support readiness checks", "plugin", name)
+			if rd.opts.strictPlugins {
+				report = append(report, notFoundReport(name, "plugin not found"))
+			}
 			continue
 		}
 
 		if svc == nil {
+			if rd.opts.strictPlugins {
+				report = append(report, notFoundReport(name, "plugin is nil or not initialized"))
+			}
 			continue
 		}
 
-		st, err := svc.Ready()
+		targets = append(targets, checkTarget{name: name, check: rd.opts.coalescer.wrap(name, svc.Ready)})
+	}
+
+	results := rd.opts.results(ctx, timeout, targets)
+	for i, res := range results {
+		name := targets[i].name
+		st, err := res.st, res.err
 		if err != nil {
-			w.WriteHeader(rd.unavailableStatusCode)
+			if rep := abortedReport(name, err); rep != nil {
+				rd.log.Warn("check aborted", "plugin", name, "error", err)
+				rep.fail = true
+				report = append(report, rep)
+				continue
+			}
+
 			report = append(report, &Report{
 				PluginName:   name,
 				ErrorMessage: err.Error(),
 				StatusCode:   http.StatusInternalServerError,
+				fail:         true,
 			})
 			continue
 		}
@@ -144,12 +186,12 @@ func (rd *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 		switch {
 		case st.Code >= 500:
-			// on >=500, write header, because it'll be written on Write (200)
-			w.WriteHeader(rd.unavailableStatusCode)
+			// on >=500, the whole probe fails
 			report = append(report, &Report{
 				PluginName:   name,
 				ErrorMessage: "internal server error, see logs",
 				StatusCode:   rd.unavailableStatusCode,
+				fail:         true,
 			})
 		case st.Code >= 100 && st.Code <= 400:
 			report = append(report, &Report{
@@ -165,14 +207,7 @@ func (rd *Ready) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	data, err := json.Marshal(report)
-	if err != nil {
-		rd.log.Error("failed to marshal response", "error", err)
-	}
+	stampReports(report[len(report)-len(results):], results)
 
-	// write the response
-	_, err = w.Write(data)
-	if err != nil {
-		rd.log.Error("failed to write response", "error", err)
-	}
+	return report
 }
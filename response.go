@@ -0,0 +1,102 @@
+package status
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Severity of a single report and overall status of a probe response.
+const (
+	// SeverityPass means the plugin is healthy.
+	SeverityPass = "pass"
+	// SeverityWarn means a plugin is unhealthy without failing the probe: it is
+	// non-critical, or its failure does not fail this probe.
+	SeverityWarn = "warn"
+	// SeverityFail means a critical plugin failed the probe, which responds with
+	// the unavailable status code.
+	SeverityFail = "fail"
+
+	// HealthStatusHeader carries the overall status of a /health or /ready response.
+	HealthStatusHeader = "X-Health-Status"
+)
+
+// notFoundReport reports a plugin named in ?plugin= that cannot be checked, in
+// strict mode. It fails the probe unless the plugin is non-critical.
+func notFoundReport(name, msg string) *Report {
+	return &Report{
+		PluginName:   name,
+		ErrorMessage: msg,
+		StatusCode:   http.StatusNotFound,
+		fail:         true,
+	}
+}
+
+// severity returns the severity of rep: a failing critical plugin fails the
+// probe, a failing non-critical plugin only warns.
+func (o *handlerOptions) severity(rep *Report) string {
+	switch {
+	case rep.fail && o.critical(rep.PluginName):
+		return SeverityFail
+	case rep.fail, rep.StatusCode < 100 || rep.StatusCode > 400:
+		return SeverityWarn
+	default:
+		return SeverityPass
+	}
+}
+
+// evaluate applies the thresholds to and sets the severity of every report,
+// records them in the history, and returns the overall status: fail if a
+// critical plugin failed, warn if any plugin is unhealthy, pass otherwise.
+func (o *handlerOptions) evaluate(report []*Report, usc int) string {
+	overall := SeverityPass
+	for _, rep := range report {
+		o.hysteresis.apply(rep, usc)
+		rep.Severity = o.severity(rep)
+
+		switch {
+		case rep.Severity == SeverityFail:
+			overall = SeverityFail
+		case rep.Severity == SeverityWarn && overall == SeverityPass:
+			overall = SeverityWarn
+		}
+	}
+
+	o.history.record(report)
+	o.metrics.record(o.probe, report)
+
+	return overall
+}
+
+// writeReports evaluates the reports, then writes them with the overall status in
+// the HealthStatusHeader, with the unavailable status code if it is fail. A
+// request accepting HealthJSONContentType gets a health+json document instead
+// of the report array.
+func (o *handlerOptions) writeReports(w http.ResponseWriter, r *http.Request, log *slog.Logger, usc int, report []*Report) {
+	overall := o.evaluate(report, usc)
+
+	var body any = report
+	if acceptsHealthJSON(r) {
+		body = healthJSON(report, overall, o.serviceID)
+		w.Header().Set("Content-Type", HealthJSONContentType)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		// TODO do we need to write this error to the ResponseWriter?
+		log.Error("failed to marshal response", "error", err)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set(HealthStatusHeader, overall)
+	if overall == SeverityFail {
+		w.WriteHeader(usc)
+	}
+
+	// write the response
+	_, err = w.Write(data)
+	if err != nil {
+		log.Error("failed to write response", "error", err)
+	}
+}
@@ -1,6 +1,7 @@
 package status
 
 import (
+	"context"
 	"log/slog"
 
 	statusV1 "github.com/roadrunner-server/api-go/v6/status/v1"
@@ -51,3 +52,119 @@ func (r *rpc) Ready(in *statusV1.Request, out *statusV1.Response) error {
 	r.log.Debug("successfully finished the Ready method")
 	return nil
 }
+
+// StatusAll returns the aggregated health report of the requested plugins, or
+// of all plugins, with the same content as /health.
+func (r *rpc) StatusAll(in *ProbeRequest, out *ProbeResponse) error {
+	const op = errors.Op("checker_rpc_status_all")
+	r.log.Debug("StatusAll method was invoked", "plugins", in.Plugins)
+
+	resp, err := r.srv.probe(context.Background(), probeHealth, in.Plugins)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = *resp
+
+	r.log.Debug("successfully finished the StatusAll method")
+	return nil
+}
+
+// ReadyAll returns the aggregated readiness report of the requested plugins, or
+// of all plugins, with the same content as /ready.
+func (r *rpc) ReadyAll(in *ProbeRequest, out *ProbeResponse) error {
+	const op = errors.Op("checker_rpc_ready_all")
+	r.log.Debug("ReadyAll method was invoked", "plugins", in.Plugins)
+
+	resp, err := r.srv.probe(context.Background(), probeReady, in.Plugins)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*out = *resp
+
+	r.log.Debug("successfully finished the ReadyAll method")
+	return nil
+}
+
+// Plugins lists the collected plugins, the interfaces they implement and the
+// probes they take part in.
+func (r *rpc) Plugins(_ *PluginsRequest, out *PluginsResponse) error {
+	r.log.Debug("Plugins method was invoked")
+
+	out.Plugins = r.srv.catalog.list()
+
+	r.log.Debug("successfully finished the Plugins method")
+	return nil
+}
+
+// Jobs returns the state of the job pipelines, filtered, sorted and paginated
+// the same way as /jobs.
+func (r *rpc) Jobs(in *JobsRequest, out *JobsResponse) error {
+	const op = errors.Op("checker_rpc_jobs")
+	r.log.Debug("Jobs method was invoked", "pipelines", in.Pipelines, "drivers", in.Drivers)
+
+	jq := jobsQuery{
+		pipelines: in.Pipelines,
+		sources:   in.Sources,
+		drivers:   in.Drivers,
+		ready:     in.Ready,
+		sort:      in.Sort,
+		offset:    in.Offset,
+		limit:     in.Limit,
+	}
+
+	err := jq.validate()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	reports, total, err := r.srv.jobs(jq)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	out.Reports, out.Total = reports, total
+
+	r.log.Debug("successfully finished the Jobs method")
+	return nil
+}
+
+// History returns the recorded check results and transitions of the requested
+// probe type and plugins.
+func (r *rpc) History(in *HistoryRequest, out *HistoryResponse) error {
+	const op = errors.Op("checker_rpc_history")
+	r.log.Debug("History method was invoked", "probe", in.Probe, "plugins", in.Plugins)
+
+	entries, err := r.srv.history(in.Probe, in.Plugins)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	out.Entries = entries
+
+	r.log.Debug("successfully finished the History method")
+	return nil
+}
+
+// SetMaintenance turns the maintenance mode on or off. While it is on, /ready
+// (and /jobs, if configured) returns the unavailable status code with the reason.
+func (r *rpc) SetMaintenance(in *MaintenanceRequest, out *MaintenanceState) error {
+	r.log.Debug("SetMaintenance method was invoked", "on", in.On, "reason", in.Reason)
+
+	*out = r.srv.maintenance.set(in.On, in.Reason)
+
+	r.log.Debug("successfully finished the SetMaintenance method")
+	return nil
+}
+
+// ShutdownState returns the drain progress: when the shutdown started and which
+// plugins are still running, checking each of them under the check timeout.
+func (r *rpc) ShutdownState(_ *ShutdownRequest, out *ShutdownState) error {
+	r.log.Debug("ShutdownState method was invoked")
+
+	*out = r.srv.drain.state(context.Background())
+
+	r.log.Debug("successfully finished the ShutdownState method")
+	return nil
+}
@@ -0,0 +1,287 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	shutdownMessage = "service is shutting down"
+
+	// pause between the checks of the plugins during the drain
+	drainInterval = time.Second
+)
+
+// ShutdownState is the drain progress, the body of every endpoint during the
+// graceful shutdown and the reply of the ShutdownState rpc method.
+type ShutdownState struct {
+	ShuttingDown bool      `json:"shutting_down"`
+	Message      string    `json:"message,omitempty"`
+	StartedAt    time.Time `json:"started_at,omitzero"`
+	ElapsedMs    int64     `json:"elapsed_ms"`
+	// plugins still passing their checks, and the ones that no longer do
+	Running []string `json:"running"`
+	Stopped []string `json:"stopped"`
+}
+
+// drain tracks the graceful shutdown of the plugin and reports which of the
+// collected plugins are still running. From the start of the shutdown it checks
+// them in the background, so the endpoints answer from the latest round.
+type drain struct {
+	timeout        time.Duration
+	concurrency    int
+	statusRegistry map[string]Checker
+	readyRegistry  map[string]Readiness
+	healthFlights  *coalescer
+	readyFlights   *coalescer
+	// the latest results of /health and /ready, reported until the first round
+	// of the drain checks finished
+	healthHistory *history
+	readyHistory  *history
+	// pause between the rounds, drainInterval if zero
+	interval time.Duration
+
+	mu        sync.Mutex
+	startedAt time.Time
+	// the plugins of the latest round, checked is false before the first one
+	running []string
+	stopped []string
+	checked bool
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// begin marks the start of the shutdown and starts checking the plugins, only
+// the first call counts.
+func (d *drain) begin() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.startedAt.IsZero() {
+		return
+	}
+
+	d.startedAt = time.Now()
+	d.stopCh = make(chan struct{})
+	go d.run(d.stopCh)
+}
+
+// end stops the checks started by begin.
+func (d *drain) end() {
+	d.mu.Lock()
+	stopCh := d.stopCh
+	d.mu.Unlock()
+
+	if stopCh != nil {
+		d.stopOnce.Do(func() { close(stopCh) })
+	}
+}
+
+// run checks the plugins in rounds, each under the check timeout, until stopCh
+// is closed.
+func (d *drain) run(stopCh chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// abort the running round on stop instead of waiting for the check timeout
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	interval := d.interval
+	if interval <= 0 {
+		interval = drainInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		running, stopped := d.check(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.mu.Lock()
+		d.running, d.stopped, d.checked = running, stopped, true
+		d.mu.Unlock()
+
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// progress returns the drain progress without the plugins, and whether the
+// shutdown started. A nil drain only knows that the service is shutting down.
+func (d *drain) progress() (ShutdownState, bool) {
+	st := ShutdownState{Running: []string{}, Stopped: []string{}}
+	if d == nil {
+		st.ShuttingDown, st.Message = true, shutdownMessage
+		return st, false
+	}
+
+	d.mu.Lock()
+	startedAt := d.startedAt
+	d.mu.Unlock()
+
+	if startedAt.IsZero() {
+		return st, false
+	}
+
+	st.ShuttingDown, st.Message = true, shutdownMessage
+	st.StartedAt = startedAt
+	st.ElapsedMs = time.Since(startedAt).Milliseconds()
+
+	return st, true
+}
+
+// state returns the drain progress, checking every plugin under the check
+// timeout.
+func (d *drain) state(ctx context.Context) ShutdownState {
+	st, ok := d.progress()
+	if !ok {
+		return st
+	}
+
+	st.Running, st.Stopped = d.check(ctx)
+
+	return st
+}
+
+// check checks every plugin under the check timeout and returns the running and
+// the stopped ones, sorted. A plugin counts as running while its Status check,
+// or the Ready check of a Readiness-only plugin, passes.
+func (d *drain) check(ctx context.Context) ([]string, []string) {
+	running, stopped := []string{}, []string{}
+
+	targets := make([]checkTarget, 0, len(d.statusRegistry)+len(d.readyRegistry))
+	for name, pl := range d.statusRegistry {
+		if pl != nil {
+			targets = append(targets, checkTarget{name: name, check: d.healthFlights.wrap(name, pl.Status)})
+		}
+	}
+	for name, pl := range d.readyRegistry {
+		if _, ok := d.statusRegistry[name]; !ok && pl != nil {
+			targets = append(targets, checkTarget{name: name, check: d.readyFlights.wrap(name, pl.Ready)})
+		}
+	}
+
+	for i, res := range runChecks(ctx, d.timeout, d.concurrency, targets) {
+		if res.err == nil && res.st != nil && res.st.Code >= 100 && res.st.Code <= 400 {
+			running = append(running, targets[i].name)
+			continue
+		}
+
+		stopped = append(stopped, targets[i].name)
+	}
+
+	slices.Sort(running)
+	slices.Sort(stopped)
+
+	return running, stopped
+}
+
+// snapshot returns the drain progress of the latest round of checks, without
+// checking any, so the endpoints answer at once during the shutdown. Until the
+// first round finished, it reports the latest recorded result of every plugin,
+// leaving out the plugins that were never checked.
+func (d *drain) snapshot() ShutdownState {
+	st, ok := d.progress()
+	if !ok {
+		return st
+	}
+
+	d.mu.Lock()
+	running, stopped, checked := d.running, d.stopped, d.checked
+	d.mu.Unlock()
+
+	if checked {
+		st.Running, st.Stopped = running, stopped
+		return st
+	}
+
+	add := func(name string, h *history) {
+		e, ok := h.latest(name)
+		switch {
+		case !ok:
+		case e.StatusCode >= 100 && e.StatusCode <= 400:
+			st.Running = append(st.Running, name)
+		default:
+			st.Stopped = append(st.Stopped, name)
+		}
+	}
+
+	for name := range d.statusRegistry {
+		add(name, d.healthHistory)
+	}
+	for name := range d.readyRegistry {
+		if _, ok := d.statusRegistry[name]; !ok {
+			add(name, d.readyHistory)
+		}
+	}
+
+	slices.Sort(st.Running)
+	slices.Sort(st.Stopped)
+
+	return st
+}
+
+// writeShutdown writes the drain progress with the given status code. A request
+// accepting HealthJSONContentType gets a health+json document instead, with the
+// shutdown as its single check: warn if code is a success, as on /health,
+// otherwise fail.
+func (o *handlerOptions) writeShutdown(w http.ResponseWriter, r *http.Request, log *slog.Logger, code int) {
+	st := o.drain.snapshot()
+
+	severity := SeverityFail
+	if code < http.StatusBadRequest {
+		severity = SeverityWarn
+	}
+
+	var body any = st
+	if acceptsHealthJSON(r) {
+		body = &HealthJSON{
+			Status:    severity,
+			ServiceID: o.serviceID,
+			Output:    st.Message,
+			Time:      time.Now(),
+			Checks: map[string][]HealthJSONCheck{"shutdown": {{
+				ComponentType: "system",
+				ObservedValue: int(st.ElapsedMs),
+				ObservedUnit:  "ms",
+				Status:        severity,
+				Time:          st.StartedAt,
+				Output:        st.Message,
+			}}},
+		}
+		w.Header().Set("Content-Type", HealthJSONContentType)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Error("failed to marshal shutdown state", "error", err)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set(HealthStatusHeader, severity)
+	w.WriteHeader(code)
+
+	_, err = w.Write(data)
+	if err != nil {
+		log.Error("failed to write shutdown state", "error", err)
+	}
+}
@@ -0,0 +1,200 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseShutdownState(t *testing.T, body []byte) ShutdownState {
+	t.Helper()
+	var st ShutdownState
+	require.NoError(t, json.Unmarshal(body, &st))
+	return st
+}
+
+func TestDrain(t *testing.T) {
+	d := &drain{
+		statusRegistry: map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+			"grpc": &mockChecker{name: "grpc", err: errors.New("pool is destroyed")},
+			"kv":   nil,
+		},
+		readyRegistry: map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 503}},
+			"jobs": &mockReadiness{name: "jobs", st: &apiStatus.Status{Code: 200}},
+		},
+	}
+
+	st := d.state(context.Background())
+	assert.False(t, st.ShuttingDown)
+	assert.Empty(t, st.Running)
+
+	d.begin()
+	t.Cleanup(d.end)
+	startedAt := d.startedAt
+	d.begin()
+	assert.Equal(t, startedAt, d.startedAt)
+
+	st = d.state(context.Background())
+	assert.True(t, st.ShuttingDown)
+	assert.Equal(t, "service is shutting down", st.Message)
+	assert.Equal(t, startedAt, st.StartedAt)
+	// the Status check decides for plugins in both registries
+	assert.Equal(t, []string{"http", "jobs"}, st.Running)
+	assert.Equal(t, []string{"grpc"}, st.Stopped)
+
+	t.Run("Nil", func(t *testing.T) {
+		var d *drain
+		st := d.state(context.Background())
+		assert.True(t, st.ShuttingDown)
+		assert.Equal(t, "service is shutting down", st.Message)
+		assert.NotNil(t, st.Running)
+		assert.NotNil(t, st.Stopped)
+	})
+}
+
+func TestShutdownResponses(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	// a hung plugin does not hold the responses up
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+
+	d := &drain{
+		statusRegistry: map[string]Checker{
+			"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}},
+			"grpc": &mockChecker{name: "grpc", block: block},
+			"kv":   &mockChecker{name: "kv", block: block},
+		},
+		readyRegistry: map[string]Readiness{},
+		healthHistory: newHistory(10),
+		// the drain checks hang on grpc and kv, the responses report the history
+		timeout: time.Hour,
+	}
+	// the latest results of /health, kv was never checked
+	d.healthHistory.record([]*Report{
+		{PluginName: "http", StatusCode: http.StatusOK, Severity: SeverityPass, CheckedAt: time.Now()},
+		{PluginName: "grpc", StatusCode: http.StatusServiceUnavailable, Severity: SeverityFail, CheckedAt: time.Now()},
+	})
+	d.begin()
+	t.Cleanup(d.end)
+
+	shutdown := newShutdownPtr(true)
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "pipe1", Ready: true}}}
+
+	for _, tt := range []struct {
+		name     string
+		handler  http.Handler
+		wantCode int
+	}{
+		{"Health", NewHealthHandler(d.statusRegistry, shutdown, log, http.StatusServiceUnavailable, withDrain(d)), http.StatusOK},
+		{"Ready", NewReadyHandler(d.readyRegistry, shutdown, log, http.StatusServiceUnavailable, withDrain(d)), http.StatusServiceUnavailable},
+		{"Startup", NewStartupHandler(d.readyRegistry, shutdown, log, http.StatusServiceUnavailable, withDrain(d), WithStartupPlugins("http")), http.StatusServiceUnavailable},
+		{"Jobs", NewJobsHandler(jc, shutdown, log, http.StatusServiceUnavailable, withDrain(d)), http.StatusServiceUnavailable},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			st := parseShutdownState(t, rec.Body.Bytes())
+			assert.True(t, st.ShuttingDown)
+			assert.False(t, st.StartedAt.IsZero())
+			assert.Equal(t, []string{"http"}, st.Running)
+			assert.Equal(t, []string{"grpc"}, st.Stopped)
+
+			// a health+json client gets the shutdown as a check
+			req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/", nil)
+			req.Header.Set("Accept", HealthJSONContentType)
+			rec = httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, HealthJSONContentType, rec.Header().Get("Content-Type"))
+
+			want := SeverityFail
+			if tt.wantCode == http.StatusOK {
+				want = SeverityWarn
+			}
+			assert.Equal(t, want, rec.Header().Get(HealthStatusHeader))
+
+			var doc HealthJSON
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+			assert.Equal(t, want, doc.Status)
+			assert.Equal(t, shutdownMessage, doc.Output)
+			require.Len(t, doc.Checks["shutdown"], 1)
+			assert.Equal(t, want, doc.Checks["shutdown"][0].Status)
+		})
+	}
+}
+
+// TestDrainRefresh checks that the drain progress follows the plugins during
+// the shutdown, including the ones no probe checked before.
+func TestDrainRefresh(t *testing.T) {
+	http1 := &switchChecker{}
+	http1.st.Store(&apiStatus.Status{Code: 200})
+	d := &drain{
+		timeout:  time.Second,
+		interval: time.Millisecond * 10,
+		statusRegistry: map[string]Checker{
+			"http": http1,
+			"grpc": &mockChecker{name: "grpc", err: errors.New("pool is destroyed")},
+		},
+		readyRegistry: map[string]Readiness{},
+		healthHistory: newHistory(10),
+	}
+
+	d.begin()
+	t.Cleanup(d.end)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		st := d.snapshot()
+		assert.Equal(c, []string{"http"}, st.Running)
+		assert.Equal(c, []string{"grpc"}, st.Stopped)
+	}, 5*time.Second, time.Millisecond*10)
+
+	http1.st.Store(&apiStatus.Status{Code: 503})
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		st := d.snapshot()
+		assert.Empty(c, st.Running)
+		assert.Equal(c, []string{"grpc", "http"}, st.Stopped)
+	}, 5*time.Second, time.Millisecond*10)
+}
+
+// switchChecker returns the status it holds, which may change while it is
+// checked concurrently.
+type switchChecker struct {
+	st atomic.Pointer[apiStatus.Status]
+}
+
+func (c *switchChecker) Status() (*apiStatus.Status, error) { return c.st.Load(), nil }
+func (c *switchChecker) Name() string                       { return "http" }
+
+func TestShutdownStateRPC(t *testing.T) {
+	p := &Plugin{}
+	require.NoError(t, p.Init(&initConfigurer{has: true}, initLogger{}))
+	p.statusRegistry["http"] = &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}}
+	r := &rpc{srv: p, log: p.log}
+
+	var out ShutdownState
+	require.NoError(t, r.ShutdownState(&ShutdownRequest{}, &out))
+	assert.False(t, out.ShuttingDown)
+
+	require.NoError(t, p.Stop(context.Background()))
+
+	require.NoError(t, r.ShutdownState(&ShutdownRequest{}, &out))
+	assert.True(t, out.ShuttingDown)
+	assert.Equal(t, []string{"http"}, out.Running)
+}
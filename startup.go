@@ -0,0 +1,216 @@
+package status
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Startup handler returns 200OK once every required Readiness plugin has reported
+// ready at least once, and keeps returning it for the rest of the process's life.
+// Until then, it checks the plugins that have not been ready yet on every
+// request and returns the unavailable status code. Within the grace period a
+// plugin that is not ready yet is a warning, and the response tells when the
+// grace period ends with Retry-After; after it, the plugin fails.
+
+type Startup struct {
+	log                   *slog.Logger
+	unavailableStatusCode int
+	statusRegistry        map[string]Readiness
+	shutdownInitiated     *atomic.Bool
+	opts                  handlerOptions
+
+	// time the handler was created, the grace period starts here
+	createdAt time.Time
+	// true once every required plugin has been ready
+	latched atomic.Bool
+
+	mu sync.Mutex
+	// time each required plugin first reported ready
+	readyAt map[string]time.Time
+}
+
+func NewStartupHandler(sr map[string]Readiness, shutdownInitiated *atomic.Bool, log *slog.Logger, usc int, opts ...HandlerOption) *Startup {
+	return &Startup{
+		log:                   log,
+		statusRegistry:        sr,
+		unavailableStatusCode: usc,
+		shutdownInitiated:     shutdownInitiated,
+		opts:                  newHandlerOptions(opts),
+		createdAt:             time.Now(),
+		readyAt:               make(map[string]time.Time),
+	}
+}
+
+func (rd *Startup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	required := rd.required()
+
+	if rd.latched.Load() {
+		w.Header().Set(HealthStatusHeader, SeverityPass)
+		rd.writeReports(w, rd.latchedReports(required))
+		return
+	}
+
+	if rd.shutdownInitiated != nil && rd.shutdownInitiated.Load() {
+		rd.opts.writeShutdown(w, r, rd.log, rd.unavailableStatusCode)
+		return
+	}
+
+	timeout, err := requestTimeout(r, rd.opts.checkTimeout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	graceLeft := rd.opts.startupGracePeriod - time.Since(rd.createdAt)
+	inGrace := graceLeft > 0
+
+	report := make([]*Report, 0, len(required))
+	targets := make([]checkTarget, 0, len(required))
+
+	rd.mu.Lock()
+	for _, name := range required {
+		if at, ok := rd.readyAt[name]; ok {
+			report = append(report, &Report{
+				PluginName: name,
+				StatusCode: http.StatusOK,
+				CheckedAt:  at,
+				Severity:   SeverityPass,
+			})
+			continue
+		}
+
+		pl, ok := rd.statusRegistry[name]
+		if !ok || pl == nil {
+			report = append(report, &Report{
+				PluginName:   name,
+				ErrorMessage: "plugin is not registered or not initialized",
+				StatusCode:   http.StatusNotFound,
+				Severity:     SeverityFail,
+			})
+			continue
+		}
+
+		targets = append(targets, checkTarget{name: name, check: rd.opts.coalescer.wrap(name, pl.Ready)})
+	}
+	rd.mu.Unlock()
+
+	results := rd.opts.results(r.Context(), timeout, targets)
+
+	rd.mu.Lock()
+	for i, res := range results {
+		name := targets[i].name
+
+		if res.err == nil && res.st != nil && res.st.Code >= 100 && res.st.Code <= 400 {
+			if _, ok := rd.readyAt[name]; !ok {
+				rd.readyAt[name] = res.checkedAt
+				rd.log.Info("plugin is ready", "plugin", name)
+			}
+
+			report = append(report, &Report{
+				PluginName: name,
+				StatusCode: res.st.Code,
+				Severity:   SeverityPass,
+			})
+			continue
+		}
+
+		rep := &Report{
+			PluginName:   name,
+			ErrorMessage: "plugin is not ready yet",
+			StatusCode:   rd.unavailableStatusCode,
+			Severity:     SeverityFail,
+		}
+		if res.err != nil {
+			rep.ErrorMessage = res.err.Error()
+		}
+
+		// failures are expected while the plugins warm up
+		if inGrace {
+			rep.Severity = SeverityWarn
+			rd.log.Debug("plugin is not ready during the startup grace period", "plugin", name, "reason", rep.ErrorMessage)
+		} else {
+			rd.log.Warn("plugin is not ready after the startup grace period", "plugin", name, "reason", rep.ErrorMessage)
+		}
+
+		report = append(report, rep)
+	}
+
+	allReady := len(rd.readyAt) == len(required)
+	rd.mu.Unlock()
+
+	stampReports(report[len(report)-len(results):], results)
+
+	if allReady && rd.latched.CompareAndSwap(false, true) {
+		rd.log.Info("startup completed, all plugins have been ready", "plugins", required)
+	}
+
+	overall := SeverityPass
+	switch {
+	case slices.ContainsFunc(report, func(rep *Report) bool { return rep.Severity == SeverityFail }):
+		overall = SeverityFail
+	case !allReady:
+		overall = SeverityWarn
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(graceLeft.Seconds()))))
+	}
+
+	w.Header().Set(HealthStatusHeader, overall)
+	if !allReady {
+		w.WriteHeader(rd.unavailableStatusCode)
+	}
+
+	rd.writeReports(w, report)
+}
+
+// required returns the plugins the startup waits for: the configured ones, or
+// every registered Readiness plugin.
+func (rd *Startup) required() []string {
+	if len(rd.opts.startupPlugins) > 0 {
+		return rd.opts.startupPlugins
+	}
+
+	names := make([]string, 0, len(rd.statusRegistry))
+	for name := range rd.statusRegistry {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// latchedReports reports every required plugin as ready since the time it first
+// reported so.
+func (rd *Startup) latchedReports(required []string) []*Report {
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	report := make([]*Report, 0, len(required))
+	for _, name := range required {
+		report = append(report, &Report{
+			PluginName: name,
+			StatusCode: http.StatusOK,
+			CheckedAt:  rd.readyAt[name],
+			Severity:   SeverityPass,
+		})
+	}
+
+	return report
+}
+
+func (rd *Startup) writeReports(w http.ResponseWriter, report []*Report) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		rd.log.Error("failed to marshal response", "error", err)
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		rd.log.Error("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,135 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupHandler(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+
+	get := func(t *testing.T, h http.Handler) (int, []*Report) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/startup", nil))
+		return rec.Code, parseReports(t, rec.Body.Bytes())
+	}
+
+	t.Run("Latch", func(t *testing.T) {
+		httpPl := &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}}
+		jobsPl := &mockReadiness{name: "jobs", err: errors.New("warming up")}
+		registry := map[string]Readiness{"http": httpPl, "jobs": jobsPl}
+
+		h := NewStartupHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable)
+
+		code, reports := get(t, h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		byName := reportsByName(reports)
+		require.Len(t, byName, 2)
+		assert.Equal(t, http.StatusOK, byName["http"].StatusCode)
+		assert.Equal(t, "warming up", byName["jobs"].ErrorMessage)
+		assert.Equal(t, http.StatusServiceUnavailable, byName["jobs"].StatusCode)
+
+		// http has been ready once, it is not asked again
+		httpPl.st = &apiStatus.Status{Code: 503}
+		jobsPl.err, jobsPl.st = nil, &apiStatus.Status{Code: 200}
+
+		code, _ = get(t, h)
+		assert.Equal(t, http.StatusOK, code)
+
+		// latched: failures and even a shutdown no longer change the answer
+		jobsPl.st = &apiStatus.Status{Code: 503}
+		h.shutdownInitiated = newShutdownPtr(true)
+
+		code, reports = get(t, h)
+		assert.Equal(t, http.StatusOK, code)
+		for _, rep := range reports {
+			assert.Equal(t, http.StatusOK, rep.StatusCode)
+			assert.False(t, rep.CheckedAt.IsZero())
+		}
+	})
+
+	t.Run("ConfiguredPlugins", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+			"kv":   &mockReadiness{name: "kv", st: &apiStatus.Status{Code: 500}},
+		}
+
+		h := NewStartupHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithStartupPlugins("http", "http"))
+
+		code, reports := get(t, h)
+		assert.Equal(t, http.StatusOK, code)
+		require.Len(t, reports, 1)
+		assert.Equal(t, "http", reports[0].PluginName)
+	})
+
+	t.Run("UnknownPlugin", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+		}
+
+		h := NewStartupHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, WithStartupPlugins("http", "grpc"))
+
+		code, reports := get(t, h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		byName := reportsByName(reports)
+		require.Len(t, byName, 2)
+		assert.Equal(t, http.StatusNotFound, byName["grpc"].StatusCode)
+	})
+
+	t.Run("ShutdownBeforeLatch", func(t *testing.T) {
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}},
+		}
+
+		h := NewStartupHandler(registry, newShutdownPtr(true), log, http.StatusServiceUnavailable)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/startup", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "service is shutting down")
+	})
+
+	t.Run("GracePeriod", func(t *testing.T) {
+		capture := &logCapture{}
+		registry := map[string]Readiness{
+			"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: 503}},
+		}
+
+		serve := func(h http.Handler) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/startup", nil))
+			return rec
+		}
+
+		// within the grace period the plugin is expected not to be ready
+		h := NewStartupHandler(registry, newShutdownPtr(false), slog.New(capture), http.StatusServiceUnavailable, WithStartupGracePeriod(time.Hour))
+		rec := serve(h)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, SeverityWarn, rec.Header().Get(HealthStatusHeader))
+		assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Equal(t, SeverityWarn, reports[0].Severity)
+		assert.Contains(t, capture.messages, "plugin is not ready during the startup grace period")
+
+		h = NewStartupHandler(registry, newShutdownPtr(false), slog.New(capture), http.StatusServiceUnavailable)
+		rec = serve(h)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, SeverityFail, rec.Header().Get(HealthStatusHeader))
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+		reports = parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		assert.Equal(t, SeverityFail, reports[0].Severity)
+		assert.Contains(t, capture.messages, "plugin is not ready after the startup grace period")
+	})
+}
@@ -33,7 +33,7 @@ const (
 	readyInitRPC  = "127.0.0.1:6006"
 )
 
-// TestStatusEndpoints drives /health, /ready and /jobs against a container
+// TestStatusEndpoints drives /health, /ready, /startup and /jobs against a container
 // running the http plugin. The rpc plugin is deliberately left out: the
 // ?plugin=http&plugin=rpc queries then prove that a name missing from the
 // registry is skipped instead of reported.
@@ -88,6 +88,15 @@ func TestStatusEndpoints(t *testing.T) {
 		assert.Equal(t, http.StatusOK, reports[0].StatusCode)
 	})
 
+	t.Run("Startup", func(t *testing.T) {
+		code, reports := helpers.GetReports(t, statusInitURL+"/startup")
+		assert.Equal(t, http.StatusOK, code)
+
+		require.Len(t, reports, 1)
+		assert.Equal(t, "http", reports[0].PluginName)
+		assert.Equal(t, http.StatusOK, reports[0].StatusCode)
+	})
+
 	t.Run("JobsWithoutJobsPlugin", func(t *testing.T) {
 		code, body := helpers.GetBody(t, statusInitURL+"/jobs")
 		assert.Equal(t, http.StatusServiceUnavailable, code)
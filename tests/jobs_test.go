@@ -11,6 +11,7 @@ import (
 	"github.com/roadrunner-server/memory/v6"
 	rpcPlugin "github.com/roadrunner-server/rpc/v6"
 	"github.com/roadrunner-server/server/v6"
+	"github.com/roadrunner-server/status/v6"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,3 +79,17 @@ func TestJobsReadiness(t *testing.T) {
 		})
 	}
 }
+
+// TestJobsRPC reads the pipelines over the rpc service instead of /jobs.
+func TestJobsRPC(t *testing.T) {
+	helpers.Start(t, jobsCfg, jobsPlugins(t), helpers.WithTCPProbe(jobsAddr))
+
+	client := helpers.RPC(t, jobsRPC)
+
+	rsp := &status.JobsResponse{}
+	require.NoError(t, client.Call("status.Jobs", &status.JobsRequest{Sort: "name", Limit: 1}, rsp))
+	assert.Equal(t, 2, rsp.Total)
+	require.Len(t, rsp.Reports, 1)
+	assert.Equal(t, "memory", rsp.Reports[0].Driver)
+	assert.True(t, rsp.Reports[0].Ready)
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"net"
 	"net/http"
 	"testing"
 	"time"
@@ -18,11 +19,12 @@ const (
 	shutdownAddr  = "127.0.0.1:34711"
 	shutdownURL   = "http://" + shutdownAddr
 	shutdownGrace = time.Second * 10
+	// shutdown_delay of the config
+	shutdownDelay = time.Second * 5
 )
 
-// TestShutdown503 checks the endpoints of a stopped container: Plugin.Stop only
-// raises the shutdown flag, so the status listener still answers while the rest
-// of the container drains.
+// TestShutdown503 checks the endpoints of a stopping container: Plugin.Stop
+// keeps the status listener answering for the shutdown delay, then closes it.
 func TestShutdown503(t *testing.T) {
 	stop := helpers.Start(t, shutdownCfg, []any{
 		&rpcPlugin.Plugin{},
@@ -35,8 +37,24 @@ func TestShutdown503(t *testing.T) {
 		helpers.WithConfigTimeout(shutdownGrace),
 	)
 
-	// returns once the container has been stopped
-	stop()
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+
+	// wait for the shutdown flag
+	deadline := time.Now().Add(shutdownDelay)
+	for {
+		code, _ := helpers.GetBody(t, shutdownURL+"/ready")
+		if code == http.StatusServiceUnavailable {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("/ready did not start failing after the container was stopped")
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
 
 	// liveness stays 200 so the orchestrator does not kill the draining process
 	code, body := helpers.GetBody(t, shutdownURL+"/health")
@@ -50,4 +68,18 @@ func TestShutdown503(t *testing.T) {
 	code, body = helpers.GetBody(t, shutdownURL+"/jobs")
 	assert.Equal(t, http.StatusServiceUnavailable, code)
 	assert.Contains(t, body, "service is shutting down")
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownGrace):
+		t.Fatal("the container did not stop")
+	}
+
+	// the listener is closed once the shutdown delay has passed
+	d := net.Dialer{Timeout: time.Second}
+	conn, err := d.DialContext(t.Context(), "tcp", shutdownAddr)
+	if err == nil {
+		_ = conn.Close()
+	}
+	assert.Error(t, err)
 }
@@ -0,0 +1,105 @@
+package status
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Thresholds are the consecutive results needed before the reported state of a
+// plugin changes, like the failureThreshold and successThreshold of a Kubernetes
+// probe.
+type Thresholds struct {
+	// consecutive failures before a passing plugin fails the probe
+	Failure int
+	// consecutive successes before a failing plugin passes the probe again
+	Success int
+}
+
+// thresholdState is the reported state of a plugin and the current streak.
+type thresholdState struct {
+	failing   bool
+	failures  int
+	successes int
+	// the check time of the last counted result, a cached result served again
+	// does not count twice
+	lastCheckedAt time.Time
+}
+
+// hysteresis keeps the reported state of every plugin with thresholds across
+// requests, so a single slow or failed check does not flip the probe.
+type hysteresis struct {
+	mu         sync.Mutex
+	thresholds map[string]Thresholds
+	states     map[string]*thresholdState
+}
+
+func newHysteresis(thresholds map[string]Thresholds) *hysteresis {
+	return &hysteresis{
+		thresholds: thresholds,
+		states:     make(map[string]*thresholdState, len(thresholds)),
+	}
+}
+
+// apply counts the result in rep and rewrites rep to the reported state: a
+// failure below the failure threshold no longer fails the probe, a success
+// below the success threshold still does. The first result of a plugin sets its
+// state directly. A result already counted is not counted again, unless it is a
+// stale cached one, which fails every request it is served to. Reports that
+// carry no check result are left alone.
+func (h *hysteresis) apply(rep *Report, usc int) {
+	if h == nil || rep.CheckedAt.IsZero() {
+		return
+	}
+
+	th, ok := h.thresholds[rep.PluginName]
+	if !ok {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	st, ok := h.states[rep.PluginName]
+	switch {
+	case !ok:
+		st = &thresholdState{failing: rep.fail}
+		h.states[rep.PluginName] = st
+		st.count(rep.fail)
+	case rep.stale, !rep.CheckedAt.Equal(st.lastCheckedAt):
+		st.count(rep.fail)
+	}
+
+	st.lastCheckedAt = rep.CheckedAt
+
+	switch {
+	case !st.failing && st.failures >= th.Failure:
+		st.failing = true
+	case st.failing && st.successes >= th.Success:
+		st.failing = false
+	}
+
+	rep.ConsecutiveFailures = st.failures
+	rep.ConsecutiveSuccesses = st.successes
+
+	switch {
+	case rep.fail && !st.failing:
+		rep.fail = false
+		rep.ErrorMessage = fmt.Sprintf("%s (failure %d of %d)", rep.ErrorMessage, st.failures, th.Failure)
+	case !rep.fail && st.failing:
+		rep.fail = true
+		rep.ErrorMessage = fmt.Sprintf("recovering (success %d of %d)", st.successes, th.Success)
+		rep.StatusCode = usc
+	}
+}
+
+func (st *thresholdState) count(failed bool) {
+	if failed {
+		st.failures++
+		st.successes = 0
+		return
+	}
+
+	st.successes++
+	st.failures = 0
+}
@@ -0,0 +1,190 @@
+package status
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHysteresis(t *testing.T) {
+	h := newHysteresis(map[string]Thresholds{"http": {Failure: 3, Success: 2}})
+
+	// observe feeds a single result of the http plugin and returns the report
+	// as rewritten by the thresholds
+	observe := func(fail bool) *Report {
+		rep := &Report{PluginName: "http", StatusCode: http.StatusOK, CheckedAt: time.Now()}
+		if fail {
+			rep.StatusCode, rep.ErrorMessage, rep.fail = http.StatusServiceUnavailable, "internal server error, see logs", true
+		}
+
+		h.apply(rep, http.StatusServiceUnavailable)
+		// distinct check times even on a coarse clock
+		time.Sleep(time.Millisecond)
+
+		return rep
+	}
+
+	rep := observe(false)
+	assert.False(t, rep.fail)
+
+	// two failures are tolerated, the third one fails the probe
+	for i := 1; i <= 2; i++ {
+		rep = observe(true)
+		assert.False(t, rep.fail)
+		assert.Equal(t, i, rep.ConsecutiveFailures)
+		assert.Contains(t, rep.ErrorMessage, "of 3)")
+	}
+
+	rep = observe(true)
+	assert.True(t, rep.fail)
+	assert.Equal(t, "internal server error, see logs", rep.ErrorMessage)
+
+	// a single success does not recover it
+	rep = observe(false)
+	assert.True(t, rep.fail)
+	assert.Equal(t, http.StatusServiceUnavailable, rep.StatusCode)
+	assert.Equal(t, "recovering (success 1 of 2)", rep.ErrorMessage)
+
+	rep = observe(false)
+	assert.False(t, rep.fail)
+	assert.Equal(t, 2, rep.ConsecutiveSuccesses)
+
+	t.Run("SameResultCountsOnce", func(t *testing.T) {
+		checkedAt := time.Now()
+		for range 5 {
+			rep := &Report{PluginName: "http", StatusCode: http.StatusServiceUnavailable, CheckedAt: checkedAt, fail: true}
+			h.apply(rep, http.StatusServiceUnavailable)
+			assert.False(t, rep.fail)
+			assert.Equal(t, 1, rep.ConsecutiveFailures)
+		}
+	})
+
+	t.Run("NoThresholds", func(t *testing.T) {
+		rep := &Report{PluginName: "grpc", StatusCode: http.StatusServiceUnavailable, CheckedAt: time.Now(), fail: true}
+		h.apply(rep, http.StatusServiceUnavailable)
+		assert.True(t, rep.fail)
+		assert.Zero(t, rep.ConsecutiveFailures)
+	})
+}
+
+func TestReadyHandlerThresholds(t *testing.T) {
+	pl := &mockReadiness{name: "http", st: &apiStatus.Status{Code: 200}}
+	h := NewReadyHandler(map[string]Readiness{"http": pl}, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable,
+		WithThresholds(map[string]Thresholds{"http": {Failure: 2, Success: 1}}))
+
+	get := func() (int, *Report) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready", nil))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		return rec.Code, reports[0]
+	}
+
+	code, _ := get()
+	assert.Equal(t, http.StatusOK, code)
+
+	pl.st = &apiStatus.Status{Code: 500}
+
+	code, rep := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, SeverityWarn, rep.Severity)
+
+	code, rep = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, SeverityFail, rep.Severity)
+
+	pl.st = &apiStatus.Status{Code: 200}
+
+	code, _ = get()
+	assert.Equal(t, http.StatusOK, code)
+}
+
+// TestThresholdsCoalesced checks that a result shared within the result TTL
+// counts as a single check, however many requests it is served to.
+func TestThresholdsCoalesced(t *testing.T) {
+	var calls atomic.Int32
+	pl := &countingChecker{calls: &calls, st: &apiStatus.Status{Code: 200}}
+	h := NewHealthHandler(map[string]Checker{"http": pl}, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable,
+		withCoalescer(newCoalescer(time.Millisecond*100)), WithThresholds(map[string]Thresholds{"http": {Failure: 3, Success: 1}}))
+
+	get := func() (int, *Report) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		return rec.Code, reports[0]
+	}
+
+	code, _ := get()
+	assert.Equal(t, http.StatusOK, code)
+
+	// a single failed check served to three requests is one failure of three
+	time.Sleep(time.Millisecond * 110)
+	pl.st = &apiStatus.Status{Code: 500}
+
+	for range 3 {
+		code, rep := get()
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, 1, rep.ConsecutiveFailures)
+		assert.Equal(t, SeverityWarn, rep.Severity)
+	}
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+// TestThresholdsStale checks that a stale cached result counts as a failure on
+// every request it is served to, although its check time does not change.
+func TestThresholdsStale(t *testing.T) {
+	cache := newResultCache(time.Hour)
+	cache.store([]checkTarget{{name: "http"}}, []checkResult{{st: &apiStatus.Status{Code: 200}, checkedAt: time.Now()}})
+
+	sr := map[string]Checker{"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: 200}}}
+	h := NewHealthHandler(sr, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable,
+		withResultCache(cache), WithThresholds(map[string]Thresholds{"http": {Failure: 3, Success: 1}}))
+
+	get := func() (int, *Report) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil))
+		reports := parseReports(t, rec.Body.Bytes())
+		require.Len(t, reports, 1)
+		return rec.Code, reports[0]
+	}
+
+	code, _ := get()
+	assert.Equal(t, http.StatusOK, code)
+
+	// the poller stopped updating the cache
+	cache.maxAge = time.Millisecond
+	time.Sleep(time.Millisecond * 5)
+
+	for i := 1; i < 3; i++ {
+		code, rep := get()
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, i, rep.ConsecutiveFailures)
+	}
+
+	code, rep := get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, SeverityFail, rep.Severity)
+}
+
+// countingChecker counts its Status calls.
+type countingChecker struct {
+	calls *atomic.Int32
+	st    *apiStatus.Status
+}
+
+func (c *countingChecker) Status() (*apiStatus.Status, error) {
+	c.calls.Add(1)
+	return c.st, nil
+}
+
+func (c *countingChecker) Name() string { return "http" }
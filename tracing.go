@@ -0,0 +1,139 @@
+package status
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the instrumentation scope of the spans.
+	tracerName = "github.com/roadrunner-server/status/v6"
+
+	// span attributes
+	attrProbe      = attribute.Key("status.probe")
+	attrPlugin     = attribute.Key("status.plugin")
+	attrStatusCode = attribute.Key("status.code")
+	attrPipelines  = attribute.Key("status.jobs.pipelines")
+)
+
+// newTracerProvider returns a tracer provider exporting the spans over OTLP/HTTP
+// to the configured endpoint. The exporter connects lazily, so an unreachable
+// collector only loses spans.
+func newTracerProvider(cfg *TracingConfig) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exp, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))),
+	), nil
+}
+
+// traced wraps h with a server span per request, continuing the trace of the
+// incoming traceparent header. The checks of the request are traced as its
+// children. A nil tp leaves h alone.
+func traced(tp trace.TracerProvider, h http.Handler) http.Handler {
+	if tp == nil {
+		return h
+	}
+
+	tracer := tp.Tracer(tracerName)
+	propagator := propagation.TraceContext{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method+" "+cmp.Or(r.Pattern, r.URL.Path),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+		h.ServeHTTP(sw, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", sw.code))
+		if sw.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.code))
+		}
+	})
+}
+
+// traceChecks records a span of every check, as a child of the span of ctx,
+// from the time it started to the time it returned. Without a span in ctx
+// nothing is recorded.
+func traceChecks(ctx context.Context, probe string, targets []checkTarget, results []checkResult) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return
+	}
+
+	tracer := parent.TracerProvider().Tracer(tracerName)
+	for i, res := range results {
+		_, span := tracer.Start(ctx, "status.check",
+			trace.WithTimestamp(res.checkedAt.Add(-res.duration)),
+			trace.WithAttributes(attrProbe.String(probe), attrPlugin.String(targets[i].name)),
+		)
+
+		if res.st != nil {
+			span.SetAttributes(attrStatusCode.Int(res.st.Code))
+		}
+		if res.err != nil {
+			span.RecordError(res.err)
+			span.SetStatus(codes.Error, res.err.Error())
+		}
+
+		span.End(trace.WithTimestamp(res.checkedAt))
+	}
+}
+
+// traceJobs starts the span of a JobsState call as a child of the span of ctx.
+// end records its result.
+func traceJobs(ctx context.Context, source string) (context.Context, func(n int, err error)) {
+	ctx, span := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName).Start(ctx, "status.jobs",
+		trace.WithAttributes(attrProbe.String(probeJobs), attrPlugin.String(source)),
+	)
+
+	return ctx, func(n int, err error) {
+		span.SetAttributes(attrPipelines.Int(n))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+
+		span.End()
+	}
+}
+
+// statusWriter records the status code written by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.code = code
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
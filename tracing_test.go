@@ -0,0 +1,128 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	jobsApi "github.com/roadrunner-server/api-plugins/v6/jobs"
+	apiStatus "github.com/roadrunner-server/api-plugins/v6/status"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttr returns the value of the attribute key of span, empty if not set.
+func spanAttr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func TestTraced(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	registry := map[string]Readiness{
+		"http": &mockReadiness{name: "http", st: &apiStatus.Status{Code: http.StatusOK}},
+		"grpc": &mockReadiness{name: "grpc", err: errors.New("no workers")},
+	}
+	jc := &mockJobsChecker{states: []*jobsApi.State{{Pipeline: "emails"}, {Pipeline: "orders"}}}
+
+	mux := http.NewServeMux()
+	mux.Handle("/ready", traced(tp, NewReadyHandler(registry, newShutdownPtr(false), log, http.StatusServiceUnavailable, withMetrics(nil, probeReady))))
+	mux.Handle("/jobs", traced(tp, NewJobsHandler(jc, newShutdownPtr(false), log, http.StatusServiceUnavailable)))
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/ready?plugin=http&plugin=grpc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 3)
+
+	// the checks end before the request
+	server := spans[2]
+	assert.Equal(t, "GET /ready", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Equal(t, int64(http.StatusServiceUnavailable), spanAttr(server, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Error, server.Status().Code)
+
+	checks := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range spans[:2] {
+		assert.Equal(t, "status.check", span.Name())
+		assert.Equal(t, server.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Equal(t, probeReady, spanAttr(span, attrProbe).AsString())
+		checks[spanAttr(span, attrPlugin).AsString()] = span
+	}
+
+	require.Contains(t, checks, "http")
+	assert.Equal(t, int64(http.StatusOK), spanAttr(checks["http"], attrStatusCode).AsInt64())
+	assert.Equal(t, codes.Unset, checks["http"].Status().Code)
+
+	require.Contains(t, checks, "grpc")
+	assert.Equal(t, codes.Error, checks["grpc"].Status().Code)
+	assert.Equal(t, "no workers", checks["grpc"].Status().Description)
+
+	t.Run("Jobs", func(t *testing.T) {
+		sr := tracetest.NewSpanRecorder()
+		tp.RegisterSpanProcessor(sr)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/jobs", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		spans := sr.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "status.jobs", spans[0].Name())
+		assert.Equal(t, "jobs", spanAttr(spans[0], attrPlugin).AsString())
+		assert.Equal(t, int64(2), spanAttr(spans[0], attrPipelines).AsInt64())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Equal(t, "GET /jobs", spans[1].Name())
+	})
+
+	// without a tracer provider the handler is served as is
+	h := &pluginsHandler{}
+	assert.Same(t, h, traced(nil, h))
+}
+
+func TestTracingExport(t *testing.T) {
+	var exported atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/v1/traces" {
+			exported.Add(1)
+		}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	t.Cleanup(collector.Close)
+
+	cfg := &Config{Tracing: &TracingConfig{Endpoint: collector.Listener.Addr().String(), Insecure: true}}
+	cfg.InitDefaults()
+	assert.Equal(t, "rr_status", cfg.Tracing.ServiceName)
+
+	tp, err := newTracerProvider(cfg.Tracing)
+	require.NoError(t, err)
+
+	registry := map[string]Checker{"http": &mockChecker{name: "http", st: &apiStatus.Status{Code: http.StatusOK}}}
+	h := traced(tp, NewHealthHandler(registry, newShutdownPtr(false), slog.New(slog.DiscardHandler), http.StatusServiceUnavailable))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/health", nil))
+
+	// shutting down flushes the batch to the collector
+	require.NoError(t, tp.Shutdown(context.Background()))
+	assert.Positive(t, exported.Load())
+}